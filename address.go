@@ -0,0 +1,252 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Address is the address of a resource, resource instance, module call or
+// module instance, relative to the module that contains it, as used in
+// moved, removed and import blocks. For example,
+// `module.network["east"].aws_subnet.public[0]`.
+type Address struct {
+	// ModulePath are the module calls that lead to the addressed object,
+	// starting with a module call in the current module. It's empty for
+	// resources in the current module.
+	ModulePath []AddressModuleStep `json:"module_path,omitempty"`
+
+	// Resource is the addressed resource, or nil if the address refers to
+	// a module call or module instance.
+	Resource *AddressResource `json:"resource,omitempty"`
+}
+
+// AddressModuleStep is a single module call within an Address.
+type AddressModuleStep struct {
+	Name string `json:"name"`
+
+	// Key is the instance key for a module call using count or for_each,
+	// which is either a string or an int, or nil if there is no key.
+	Key interface{} `json:"key,omitempty"`
+}
+
+// AddressResource is the resource part of an Address.
+type AddressResource struct {
+	Mode ResourceMode `json:"mode"`
+	Type string       `json:"type"`
+	Name string       `json:"name"`
+
+	// Key is the instance key for a resource using count or for_each, which
+	// is either a string or an int, or nil if there is no key.
+	Key interface{} `json:"key,omitempty"`
+
+	// KeyExpr is the expression for an instance key that can only be known
+	// by evaluation, such as each.key in an import block using for_each.
+	// Key is nil whenever KeyExpr is set.
+	KeyExpr *Expression `json:"key_expr,omitempty"`
+}
+
+// String returns the address in the syntax used in configuration.
+func (a *Address) String() string {
+	var parts []string
+	for _, step := range a.ModulePath {
+		parts = append(parts, "module."+step.Name+addressKeyString(step.Key))
+	}
+	if r := a.Resource; r != nil {
+		var part string
+		if r.Mode == DataResourceMode {
+			part = "data."
+		}
+		part += r.Type + "." + r.Name
+		if r.KeyExpr != nil {
+			part += "[" + r.KeyExpr.Source + "]"
+		} else {
+			part += addressKeyString(r.Key)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// IsModule returns true if the address refers to a module call or module
+// instance rather than to a resource.
+func (a *Address) IsModule() bool {
+	return a.Resource == nil
+}
+
+// configKey returns the key of the addressed object's declaration within
+// the current module, which is either a key of Module.ManagedResources or
+// Module.DataResources, or a key of Module.ModuleCalls prefixed by
+// "module.".
+func (a *Address) configKey() string {
+	if len(a.ModulePath) > 0 {
+		return "module." + a.ModulePath[0].Name
+	}
+	r := &Resource{Mode: a.Resource.Mode, Type: a.Resource.Type, Name: a.Resource.Name}
+	return r.MapKey()
+}
+
+func addressKeyString(key interface{}) string {
+	switch key := key.(type) {
+	case string:
+		return "[" + strconv.Quote(key) + "]"
+	case int:
+		return "[" + strconv.Itoa(key) + "]"
+	default:
+		return ""
+	}
+}
+
+// decodeAddress decodes an address from the given expression. If allowKeyExpr
+// is set then the instance key of a resource may be an arbitrary expression,
+// which must belong to the given file.
+func decodeAddress(expr hcl.Expression, file *hcl.File, allowKeyExpr bool, opts *decodeOptions) (*Address, hcl.Diagnostics) {
+	var keyExpr *Expression
+	if indexExpr, ok := expr.(*hclsyntax.IndexExpr); ok && allowKeyExpr {
+		expr = indexExpr.Collection
+		keyExpr = newExpression(indexExpr.Key, file, opts)
+	}
+
+	traversal, diags := hcl.AbsTraversalForExpr(expr)
+	if diags.HasErrors() {
+		return nil, addressError(expr.Range(), "An address must be a static reference to a resource or module call, like aws_instance.example or module.network[0].", opts)
+	}
+
+	addr, diags := parseAddress(traversal, opts)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if keyExpr != nil {
+		if addr.Resource == nil || addr.Resource.Key != nil {
+			return nil, addressError(expr.Range(), "An instance key expression is allowed only at the end of a resource address.", opts)
+		}
+		addr.Resource.KeyExpr = keyExpr
+	}
+	return addr, nil
+}
+
+// parseAddress parses an address from the given traversal.
+func parseAddress(traversal hcl.Traversal, opts *decodeOptions) (*Address, hcl.Diagnostics) {
+	rng := traversal.SourceRange()
+	addr := &Address{}
+
+	// name returns the name at the given step, or an empty string if the
+	// step is not a name.
+	name := func(i int) string {
+		if i >= len(traversal) {
+			return ""
+		}
+		switch step := traversal[i].(type) {
+		case hcl.TraverseRoot:
+			return step.Name
+		case hcl.TraverseAttr:
+			return step.Name
+		default:
+			return ""
+		}
+	}
+	// key returns the instance key at the given step, if any, and whether
+	// the step was an index step.
+	key := func(i int) (interface{}, bool, hcl.Diagnostics) {
+		if i >= len(traversal) {
+			return nil, false, nil
+		}
+		index, ok := traversal[i].(hcl.TraverseIndex)
+		if !ok {
+			return nil, false, nil
+		}
+		k, err := addressKey(index.Key)
+		if err != nil {
+			return nil, true, addressError(index.SrcRange, err.Error(), opts)
+		}
+		return k, true, nil
+	}
+
+	i := 0
+	for name(i) == "module" {
+		callName := name(i + 1)
+		if callName == "" {
+			return nil, addressError(rng, "The keyword \"module\" must be followed by the name of a module call.", opts)
+		}
+		step := AddressModuleStep{Name: callName}
+		i += 2
+		k, isIndex, diags := key(i)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		if isIndex {
+			step.Key = k
+			i++
+		}
+		addr.ModulePath = append(addr.ModulePath, step)
+	}
+	if i == len(traversal) {
+		return addr, nil
+	}
+
+	r := &AddressResource{Mode: ManagedResourceMode}
+	if name(i) == "data" {
+		r.Mode = DataResourceMode
+		i++
+	}
+	r.Type, r.Name = name(i), name(i+1)
+	if r.Type == "" || r.Name == "" {
+		return nil, addressError(rng, "A resource address must include both a resource type and a resource name, like aws_instance.example.", opts)
+	}
+	i += 2
+	k, isIndex, diags := key(i)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if isIndex {
+		r.Key = k
+		i++
+	}
+	if i != len(traversal) {
+		return nil, addressError(rng, "A resource address must end with the resource name or instance key; it can't refer to an attribute of the resource.", opts)
+	}
+	addr.Resource = r
+	return addr, nil
+}
+
+// addressKey converts the given instance key value to a string or an int.
+func addressKey(val cty.Value) (interface{}, error) {
+	if val.IsNull() || !val.IsKnown() {
+		return nil, fmt.Errorf("An instance key must be a string or a whole number.")
+	}
+	switch val.Type() {
+	case cty.String:
+		return val.AsString(), nil
+	case cty.Number:
+		bf := val.AsBigFloat()
+		if !bf.IsInt() {
+			return nil, fmt.Errorf("An instance key must be a string or a whole number.")
+		}
+		i, acc := bf.Int64()
+		if acc != big.Exact {
+			return nil, fmt.Errorf("An instance key is out of range.")
+		}
+		return int(i), nil
+	default:
+		return nil, fmt.Errorf("An instance key must be a string or a whole number.")
+	}
+}
+
+func addressError(rng hcl.Range, detail string, opts *decodeOptions) hcl.Diagnostics {
+	return hcl.Diagnostics{
+		opts.diagnostic("invalid-address", &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid address",
+			Detail:   detail,
+			Subject:  rng.Ptr(),
+		}),
+	}
+}
@@ -23,25 +23,31 @@ type Attribute struct {
 	// `resource.something.id`, `module.something.id`, or `function(something)`), then the
 	// text of that calculated value is the value of `Value`.
 	Value cty.Value
+	// References are the references the attribute's expression makes to other objects in the
+	// module.
+	References []Reference
 }
 
 // Attributes is a map of Attribute objects by the name of the attribute.
 type Attributes map[string]*Attribute
 
 // NewAttributesFromBody constructs a map of Attributes from an HCL body object.
+// Any nested blocks in the body are ignored.
 func NewAttributesFromBody(body hcl.Body, file *hcl.File) (mas Attributes, diags hcl.Diagnostics) {
-	attrs, attrDiags := body.JustAttributes()
-	diags = append(diags, attrDiags...)
-	if attrDiags.HasErrors() {
-		return nil, diags
-	}
-	mas, attrDiags = NewAttributes(attrs, file)
-	diags = append(diags, attrDiags...)
+	return newAttributesFromBody(body, file, newDecodeOptions(LoadOptions{}))
+}
+
+func newAttributesFromBody(body hcl.Body, file *hcl.File, opts *decodeOptions) (mas Attributes, diags hcl.Diagnostics) {
+	mas, _, diags = decodeBody(body, file, nil, opts)
 	return mas, diags
 }
 
 // NewAttributes contructs as map of Attributes from the raw HCL attributes.
 func NewAttributes(attrs hcl.Attributes, file *hcl.File) (mas Attributes, diags hcl.Diagnostics) {
+	return newAttributes(attrs, file, nil, newDecodeOptions(LoadOptions{}))
+}
+
+func newAttributes(attrs hcl.Attributes, file *hcl.File, iterators map[string]bool, opts *decodeOptions) (mas Attributes, diags hcl.Diagnostics) {
 	if len(attrs) == 0 {
 		return nil, nil
 	}
@@ -49,7 +55,8 @@ func NewAttributes(attrs hcl.Attributes, file *hcl.File) (mas Attributes, diags
 	mas = make(Attributes, len(attrs))
 	for k, v := range attrs {
 		ma := Attribute{
-			Attribute: v,
+			Attribute:  v,
+			References: referencesForExpr(v.Expr, iterators, opts),
 		}
 		var val cty.Value
 		var valDiags hcl.Diagnostics
@@ -64,12 +71,12 @@ func NewAttributes(attrs hcl.Attributes, file *hcl.File) (mas Attributes, diags
 			rangeBytes := make([]byte, v.Expr.Range().End.Byte-v.Expr.Range().Start.Byte)
 			_, err := reader.ReadAt(rangeBytes, int64(v.Expr.Range().Start.Byte))
 			if err != nil {
-				diags = append(diags, &hcl.Diagnostic{
+				diags = append(diags, opts.diagnostic("read-attribute-failed", &hcl.Diagnostic{
 					Severity: hcl.DiagError,
 					Summary:  "Error reading attribute offset",
 					Detail:   fmt.Sprintf("An error was encountered reading the raw offset data for attribute %q: %v", k, err),
 					Subject:  &v.Range,
-				})
+				}))
 				continue
 			}
 			valStr, err := strconv.Unquote(string(rangeBytes))
@@ -101,11 +108,11 @@ func NewAttributes(attrs hcl.Attributes, file *hcl.File) (mas Attributes, diags
 
 			val, err = gocty.ToCtyValue(valToConvert, ct)
 			if err != nil {
-				diags = append(diags, &hcl.Diagnostic{
+				diags = append(diags, opts.diagnostic("invalid-attribute-value", &hcl.Diagnostic{
 					Severity: hcl.DiagError,
 					Summary:  "Error converting attribute value to gocty value",
 					Detail:   fmt.Sprintf("An error was encountered converting the value for attribute %q: %v", k, err),
-				})
+				}))
 			}
 		}
 		ma.Value = val
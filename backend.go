@@ -0,0 +1,173 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
+// Backend represents a "backend" block within a "terraform" block, which
+// determines where a root module's state is stored.
+type Backend struct {
+	Type string `json:"type"`
+
+	// Attributes and Blocks are the backend's configuration arguments, like
+	// the bucket and key for the "s3" backend.
+	Attributes Attributes `json:"attributes,omitempty"`
+	Blocks     []*Block   `json:"blocks,omitempty"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// Cloud represents a "cloud" block within a "terraform" block, which
+// connects a root module to HCP Terraform or Terraform Enterprise.
+//
+// Any of the fields may be empty, because they can also be set by
+// environment variables when running Terraform.
+type Cloud struct {
+	Organization string           `json:"organization,omitempty"`
+	Hostname     string           `json:"hostname,omitempty"`
+	Workspaces   *CloudWorkspaces `json:"workspaces,omitempty"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// CloudWorkspaces represents the "workspaces" block within a "cloud" block,
+// which selects either a single workspace by name or a set of workspaces by
+// tags.
+type CloudWorkspaces struct {
+	Name string `json:"name,omitempty"`
+
+	// Tags are the tag names that select workspaces, when tags is set to a
+	// list of names. KeyValueTags are the tags instead, when tags is set to
+	// a map of tag names to values.
+	Tags         []string          `json:"tags,omitempty"`
+	KeyValueTags map[string]string `json:"key_value_tags,omitempty"`
+
+	Project string `json:"project,omitempty"`
+}
+
+// ProviderMeta represents a "provider_meta" block within a "terraform"
+// block, which passes module metadata to a provider.
+type ProviderMeta struct {
+	Provider   string     `json:"provider"`
+	Attributes Attributes `json:"attributes,omitempty"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+func decodeBackendBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*Backend, hcl.Diagnostics) {
+	attrs, blocks, diags := decodeBody(block.Body, file, nil, opts)
+	return &Backend{
+		Type:       block.Labels[0],
+		Attributes: attrs,
+		Blocks:     blocks,
+		Pos:        sourcePosHCL(block.DefRange, opts),
+	}, diags
+}
+
+func decodeCloudBlock(block *hcl.Block, opts *decodeOptions) (*Cloud, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(cloudSchema)
+
+	c := &Cloud{
+		Pos: sourcePosHCL(block.DefRange, opts),
+	}
+
+	if attr, defined := content.Attributes["organization"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &c.Organization)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, defined := content.Attributes["hostname"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &c.Hostname)
+		diags = append(diags, valDiags...)
+	}
+
+	for _, block := range content.Blocks {
+		wsContent, _, wsDiags := block.Body.PartialContent(cloudWorkspacesSchema)
+		diags = append(diags, wsDiags...)
+
+		ws := &CloudWorkspaces{}
+
+		if attr, defined := wsContent.Attributes["name"]; defined {
+			valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ws.Name)
+			diags = append(diags, valDiags...)
+		}
+
+		if attr, defined := wsContent.Attributes["tags"]; defined {
+			diags = append(diags, decodeCloudTags(attr, ws, opts)...)
+		}
+
+		if attr, defined := wsContent.Attributes["project"]; defined {
+			valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ws.Project)
+			diags = append(diags, valDiags...)
+		}
+
+		if ws.Name != "" && (len(ws.Tags) != 0 || len(ws.KeyValueTags) != 0) {
+			diags = append(diags, opts.diagnostic("invalid-cloud-workspaces", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid workspaces configuration",
+				Detail:   "Only one of the workspace name or tags may be set.",
+				Subject:  block.DefRange.Ptr(),
+			}))
+		}
+
+		c.Workspaces = ws
+	}
+
+	return c, diags
+}
+
+// decodeCloudTags decodes the tags argument of a workspaces block, which is
+// either a list of tag names or a map of tag names to values.
+func decodeCloudTags(attr *hcl.Attribute, ws *CloudWorkspaces, opts *decodeOptions) hcl.Diagnostics {
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return diags
+	}
+	switch ty := val.Type(); {
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		return gohcl.DecodeExpression(attr.Expr, nil, &ws.Tags)
+	case ty.IsMapType() || ty.IsObjectType():
+		return gohcl.DecodeExpression(attr.Expr, nil, &ws.KeyValueTags)
+	default:
+		return hcl.Diagnostics{
+			opts.diagnostic("unsupported-cloud-tags", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported tags form",
+				Detail:   "The workspace tags must be either a list of tag names or a map of tag names to values.",
+				Subject:  attr.Expr.Range().Ptr(),
+			}),
+		}
+	}
+}
+
+func decodeExperiments(attr *hcl.Attribute, opts *decodeOptions) ([]string, hcl.Diagnostics) {
+	exprs, diags := hcl.ExprList(attr.Expr)
+	var experiments []string
+	for _, expr := range exprs {
+		name := hcl.ExprAsKeyword(expr)
+		if name == "" {
+			diags = append(diags, opts.diagnostic("invalid-experiment-keyword", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid experiment keyword",
+				Detail:   "Elements of the experiments list must be experiment names, written as bare keywords.",
+				Subject:  expr.Range().Ptr(),
+			}))
+			continue
+		}
+		experiments = append(experiments, name)
+	}
+	return experiments, diags
+}
+
+func decodeProviderMetaBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*ProviderMeta, hcl.Diagnostics) {
+	attrs, diags := newAttributesFromBody(block.Body, file, opts)
+	return &ProviderMeta{
+		Provider:   block.Labels[0],
+		Attributes: attrs,
+		Pos:        sourcePosHCL(block.DefRange, opts),
+	}, diags
+}
@@ -0,0 +1,113 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestModuleBackend(t *testing.T) {
+	mod, diags := LoadModule("testdata/backend")
+
+	type diagSummary struct {
+		Severity DiagSeverity
+		Summary  string
+		Filename string
+		Line     int
+	}
+	var got []diagSummary
+	for _, diag := range diags {
+		got = append(got, diagSummary{diag.Severity, diag.Summary, diag.Pos.Filename, diag.Pos.Line})
+	}
+	want := []diagSummary{
+		{DiagError, "Duplicate backend configuration", "testdata/backend/state.tf", 2},
+		{DiagError, "Both a backend and a cloud configuration are present", "testdata/backend/state.tf", 6},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+
+	if mod.Backend == nil || mod.Backend.Type != "s3" {
+		t.Fatalf("wrong backend %#v", mod.Backend)
+	}
+	for name, want := range map[string]string{
+		"bucket": "acme-terraform-state",
+		"key":    "network/prod.tfstate",
+	} {
+		attr := mod.Backend.Attributes[name]
+		if attr == nil || attr.Value.AsString() != want {
+			t.Errorf("wrong value for backend attribute %s", name)
+		}
+	}
+	if diff := cmp.Diff([]string{"module_variable_optional_attrs"}, mod.Experiments); diff != "" {
+		t.Errorf("wrong experiments\n%s", diff)
+	}
+	if pm := mod.ProviderMeta["aws"]; pm == nil || pm.Attributes["module_name"] == nil {
+		t.Errorf("wrong provider_meta %#v", pm)
+	}
+}
+
+func TestModuleCloudOverride(t *testing.T) {
+	mod, diags := LoadModule("testdata/cloud")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	if mod.Backend != nil {
+		t.Errorf("backend was not replaced by the cloud block in the override file")
+	}
+	want := &Cloud{
+		Organization: "acme",
+		Hostname:     "app.terraform.io",
+		Workspaces: &CloudWorkspaces{
+			Tags:    []string{"app", "prod"},
+			Project: "platform",
+		},
+		Pos: SourcePos{Filename: "testdata/cloud/override.tf", Line: 2},
+	}
+	if diff := cmp.Diff(want, mod.Cloud); diff != "" {
+		t.Errorf("wrong cloud configuration\n%s", diff)
+	}
+}
+
+func TestDecodeCloudBlockTags(t *testing.T) {
+	tests := map[string]struct {
+		Tags         []string
+		KeyValueTags map[string]string
+		Summary      string
+	}{
+		`["app", "prod"]`:               {Tags: []string{"app", "prod"}},
+		`{ app = "web", env = "prod" }`: {KeyValueTags: map[string]string{"app": "web", "env": "prod"}},
+		`"app"`:                         {Summary: "Unsupported tags form"},
+	}
+	for src, want := range tests {
+		t.Run(src, func(t *testing.T) {
+			config := "cloud {\n  workspaces {\n    tags = " + src + "\n  }\n}\n"
+			file, diags := hclsyntax.ParseConfig([]byte(config), "test.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", diags.Error())
+			}
+			content, _ := file.Body.Content(terraformBlockSchema)
+			c, diags := decodeCloudBlock(content.Blocks[0], newDecodeOptions(LoadOptions{}))
+			if want.Summary != "" {
+				if !diags.HasErrors() || diags[0].Summary != want.Summary {
+					t.Errorf("wrong diagnostics: %s", diags.Error())
+				}
+				return
+			}
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Error())
+			}
+			if diff := cmp.Diff(want.Tags, c.Workspaces.Tags); diff != "" {
+				t.Errorf("wrong tags\n%s", diff)
+			}
+			if diff := cmp.Diff(want.KeyValueTags, c.Workspaces.KeyValueTags); diff != "" {
+				t.Errorf("wrong key-value tags\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Block represents a block nested within a resource or other configuration
+// block, like an "ingress" block in a security group resource.
+//
+// Blocks are decoded without access to the provider schema, so in JSON
+// configuration files, where blocks and attributes are written the same way,
+// nested blocks are always represented as attributes with object values.
+type Block struct {
+	Type       string     `json:"type"`
+	Labels     []string   `json:"labels,omitempty"`
+	Attributes Attributes `json:"attributes,omitempty"`
+	Blocks     []*Block   `json:"blocks,omitempty"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// decodeBody decodes all of the attributes and nested blocks in the given
+// body, which must belong to the given file, without using a schema.
+//
+// Any attributes or blocks that were already consumed by a call to
+// PartialContent that produced the given body are excluded. iterators are
+// the names of the iterators of any enclosing dynamic blocks.
+func decodeBody(body hcl.Body, file *hcl.File, iterators map[string]bool, opts *decodeOptions) (Attributes, []*Block, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		// Other syntaxes can't distinguish blocks from attributes without
+		// a schema, so we'll treat everything as attributes.
+		attrs, attrDiags := body.JustAttributes()
+		diags = append(diags, attrDiags...)
+		mas, attrDiags := newAttributes(attrs, file, iterators, opts)
+		diags = append(diags, attrDiags...)
+		return mas, nil, diags
+	}
+
+	// We build a schema that accepts everything in the body, which means
+	// PartialContent will exclude anything that was already consumed.
+	schema := &hcl.BodySchema{}
+	for name := range syntaxBody.Attributes {
+		schema.Attributes = append(schema.Attributes, hcl.AttributeSchema{Name: name})
+	}
+	seenTypes := make(map[string]bool)
+	for _, block := range syntaxBody.Blocks {
+		if seenTypes[block.Type] {
+			continue
+		}
+		seenTypes[block.Type] = true
+		labelNames := make([]string, len(block.Labels))
+		for i := range labelNames {
+			labelNames[i] = "label"
+		}
+		schema.Blocks = append(schema.Blocks, hcl.BlockHeaderSchema{
+			Type:       block.Type,
+			LabelNames: labelNames,
+		})
+	}
+
+	content, _, contentDiags := body.PartialContent(schema)
+	diags = append(diags, contentDiags...)
+
+	mas, attrDiags := newAttributes(content.Attributes, file, iterators, opts)
+	diags = append(diags, attrDiags...)
+
+	var blocks []*Block
+	for _, block := range content.Blocks {
+		b := &Block{
+			Type:   block.Type,
+			Labels: block.Labels,
+			Pos:    sourcePosHCL(block.DefRange, opts),
+		}
+		var blockDiags hcl.Diagnostics
+		b.Attributes, b.Blocks, blockDiags = decodeBody(block.Body, file, dynamicIterators(block, iterators), opts)
+		diags = append(diags, blockDiags...)
+		blocks = append(blocks, b)
+	}
+
+	return mas, blocks, diags
+}
+
+// dynamicIterators returns the names of the iterators that are in scope
+// within the body of the given block, given those in scope outside of it.
+// A dynamic block adds an iterator named by its "iterator" argument, or
+// otherwise by its label.
+func dynamicIterators(block *hcl.Block, iterators map[string]bool) map[string]bool {
+	if block.Type != "dynamic" || len(block.Labels) == 0 {
+		return iterators
+	}
+	name := block.Labels[0]
+	if body, ok := block.Body.(*hclsyntax.Body); ok {
+		if attr, ok := body.Attributes["iterator"]; ok {
+			if keyword := hcl.ExprAsKeyword(attr.Expr); keyword != "" {
+				name = keyword
+			}
+		}
+	}
+	scope := make(map[string]bool, len(iterators)+1)
+	for k := range iterators {
+		scope[k] = true
+	}
+	scope[name] = true
+	return scope
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Check represents a "check" block, which makes assertions about the
+// infrastructure without blocking operations when they fail.
+type Check struct {
+	Name string `json:"name"`
+
+	// DataResource is the check's scoped data resource, if any. It can be
+	// referred to only from within the check block, so it does not appear in
+	// Module.DataResources.
+	DataResource *Resource `json:"data_resource,omitempty"`
+
+	Asserts []*CheckRule `json:"asserts,omitempty"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// decodeCheckBlock decodes a "check" block from the given file.
+func decodeCheckBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*Check, hcl.Diagnostics) {
+	content, diags := block.Body.Content(checkBlockSchema)
+
+	c := &Check{
+		Name: block.Labels[0],
+		Pos:  sourcePosHCL(block.DefRange, opts),
+	}
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "data":
+			r, rDiags := decodeResourceBlock(block, file, opts)
+			diags = append(diags, rDiags...)
+			if c.DataResource != nil {
+				diags = append(diags, opts.diagnostic("multiple-check-data-resources", &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Multiple data resource blocks",
+					Detail:   fmt.Sprintf("This check block already has a data resource, %s, defined at %s:%d. A check block can contain at most one data resource.", c.DataResource.MapKey(), c.DataResource.Pos.Filename, c.DataResource.Pos.Line),
+					Subject:  block.DefRange.Ptr(),
+				}))
+				continue
+			}
+			c.DataResource = r
+
+		case "assert":
+			cr, crDiags := decodeCheckRule(block, file, opts)
+			diags = append(diags, crDiags...)
+			c.Asserts = append(c.Asserts, cr)
+		}
+	}
+
+	if len(c.Asserts) == 0 {
+		diags = append(diags, opts.diagnostic("missing-check-assert", &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing assert block",
+			Detail:   fmt.Sprintf("The check block %q must contain at least one assert block.", c.Name),
+			Subject:  block.DefRange.Ptr(),
+		}))
+	}
+
+	return c, diags
+}
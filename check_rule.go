@@ -0,0 +1,49 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// CheckRule represents a custom condition, such as a "validation" block in
+// an input variable, that must hold for the configuration to be valid.
+type CheckRule struct {
+	// Condition is the expression that must return true for the rule to
+	// pass. Its references describe which objects the rule depends on.
+	Condition *Expression `json:"condition"`
+
+	// ErrorMessage is the message reported when the condition fails. If the
+	// message is not a constant string then it is the raw source text of
+	// its expression instead.
+	ErrorMessage string `json:"error_message"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// decodeCheckRule decodes a block containing a custom condition, like a
+// "validation" block, from the given file.
+func decodeCheckRule(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*CheckRule, hcl.Diagnostics) {
+	content, diags := block.Body.Content(checkRuleSchema)
+
+	cr := &CheckRule{
+		Pos: sourcePosHCL(block.DefRange, opts),
+	}
+
+	if attr, defined := content.Attributes["condition"]; defined {
+		cr.Condition = newExpression(attr.Expr, file, opts)
+	}
+
+	if attr, defined := content.Attributes["error_message"]; defined {
+		val, valDiags := attr.Expr.Value(nil)
+		if !valDiags.HasErrors() && val.Type() == cty.String && val.IsKnown() && !val.IsNull() {
+			cr.ErrorMessage = val.AsString()
+		} else {
+			cr.ErrorMessage = string(attr.Expr.Range().SliceBytes(file.Bytes))
+		}
+	}
+
+	return cr, diags
+}
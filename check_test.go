@@ -0,0 +1,56 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleChecks(t *testing.T) {
+	mod, diags := LoadModule("testdata/checks")
+
+	type diagSummary struct {
+		Severity DiagSeverity
+		Summary  string
+		Line     int
+	}
+	var got []diagSummary
+	for _, diag := range diags {
+		got = append(got, diagSummary{diag.Severity, diag.Summary, diag.Pos.Line})
+	}
+	want := []diagSummary{
+		{DiagError, "Missing assert block", 28},
+		{DiagError, "Multiple data resource blocks", 36},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+
+	health := mod.Checks["health"]
+	if health == nil {
+		t.Fatalf("no check named health")
+	}
+	if r := health.DataResource; r == nil || r.MapKey() != "data.http.endpoint" || r.Mode != DataResourceMode || r.Pos.Line != 6 {
+		t.Errorf("wrong scoped data resource %#v", r)
+	}
+	if _, exists := mod.DataResources["data.http.endpoint"]; exists {
+		t.Errorf("scoped data resource is also a module data resource")
+	}
+	var gotAsserts []string
+	for _, cr := range health.Asserts {
+		gotAsserts = append(gotAsserts, cr.Condition.Source)
+	}
+	wantAsserts := []string{
+		"data.http.endpoint.status_code == 200",
+		"can(jsondecode(data.http.endpoint.response_body))",
+	}
+	if diff := cmp.Diff(wantAsserts, gotAsserts); diff != "" {
+		t.Errorf("wrong assertions\n%s", diff)
+	}
+	if _, exists := mod.RequiredProviders["http"]; !exists {
+		t.Errorf("no implied requirement for the scoped data resource's provider")
+	}
+}
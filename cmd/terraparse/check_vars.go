@@ -0,0 +1,53 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	flag "github.com/spf13/pflag"
+	"github.com/yardbirdsax/terraparse"
+)
+
+// runCheckVars implements the "check-vars" subcommand, which validates
+// variable definitions files against the module in the given directory.
+func runCheckVars(args []string) {
+	flags := flag.NewFlagSet("check-vars", flag.ExitOnError)
+	varFiles := flags.StringArray("var-file", nil, "a variable definitions file to check, in addition to those loaded automatically; may be repeated")
+	noAuto := flags.Bool("no-auto", false, "don't check the terraform.tfvars and *.auto.tfvars files in the module directory")
+	flags.Parse(args)
+
+	var dir string
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	} else {
+		dir = "."
+	}
+
+	fs := terraparse.NewOsFs()
+	module, diags := terraparse.LoadModuleFromFilesystem(fs, dir)
+
+	var paths []string
+	if !*noAuto {
+		autoPaths, autoDiags := terraparse.AutoVariableFiles(fs, dir)
+		diags = append(diags, autoDiags...)
+		paths = append(paths, autoPaths...)
+	}
+	paths = append(paths, *varFiles...)
+
+	values, valuesDiags := terraparse.LoadVariableValues(fs, paths...)
+	diags = append(diags, valuesDiags...)
+	if !valuesDiags.HasErrors() {
+		diags = append(diags, module.ValidateInputs(values)...)
+	}
+
+	for _, diag := range diags {
+		showDiagnostic(diag)
+	}
+	if diags.HasErrors() {
+		os.Exit(1)
+	}
+	fmt.Printf("%d variable definitions files are valid for %s\n", len(paths), dir)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	flag "github.com/spf13/pflag"
+	"github.com/yardbirdsax/terraparse"
+)
+
+// runGraph implements the "graph" subcommand, which prints the dependency
+// graph of the module in the given directory.
+func runGraph(args []string) {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	graphJSON := flags.Bool("json", false, "produce JSON-formatted output instead of Graphviz DOT")
+	dependentsOf := flags.String("dependents-of", "", "show only the objects that depend, directly or indirectly, on the given address")
+	flags.Parse(args)
+
+	var dir string
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	} else {
+		dir = "."
+	}
+
+	module, diags := terraparse.LoadModule(dir)
+	graph, graphDiags := module.Graph()
+	diags = append(diags, graphDiags...)
+	for _, diag := range diags {
+		showDiagnostic(diag)
+	}
+
+	switch {
+	case *dependentsOf != "":
+		if graph.Node(*dependentsOf) == nil {
+			fmt.Fprintf(os.Stderr, "no object with address %q in the module\n", *dependentsOf)
+			os.Exit(1)
+		}
+		for _, addr := range graph.TransitiveDependents(*dependentsOf) {
+			fmt.Println(addr)
+		}
+	case *graphJSON:
+		j, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error producing JSON: %s\n", err)
+			os.Exit(2)
+		}
+		os.Stdout.Write(j)
+		os.Stdout.Write([]byte{'\n'})
+	default:
+		if err := graph.WriteDOT(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing graph: %s\n", err)
+			os.Exit(2)
+		}
+	}
+
+	if diags.HasErrors() {
+		os.Exit(1)
+	}
+}
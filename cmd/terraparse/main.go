@@ -13,8 +13,20 @@ import (
 )
 
 var showJSON = flag.Bool("json", false, "produce JSON-formatted output")
+var sourceRanges = flag.Bool("source-ranges", false, "include the exact source range of each element in JSON output")
 
 func main() {
+	if len(os.Args) > 1 && !isDir(os.Args[1]) {
+		switch os.Args[1] {
+		case "graph":
+			runGraph(os.Args[2:])
+			return
+		case "check-vars":
+			runCheckVars(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Parse()
 
 	var dir string
@@ -24,7 +36,9 @@ func main() {
 		dir = "."
 	}
 
-	module, _ := terraparse.LoadModule(dir)
+	module, _ := terraparse.LoadModuleWithOptions(terraparse.NewOsFs(), dir, terraparse.LoadOptions{
+		SourceRanges: *sourceRanges,
+	})
 
 	if *showJSON {
 		showModuleJSON(module)
@@ -37,6 +51,14 @@ func main() {
 	}
 }
 
+// isDir returns true if the given path is an existing directory. A module
+// directory that happens to share its name with a subcommand is loaded as a
+// module, rather than being taken as that subcommand.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
 func showModuleJSON(module *terraparse.Module) {
 	j, err := json.MarshalIndent(module, "", "  ")
 	if err != nil {
@@ -54,3 +76,11 @@ func showModuleMarkdown(module *terraparse.Module) {
 		os.Exit(2)
 	}
 }
+
+func showDiagnostic(diag terraparse.Diagnostic) {
+	if diag.Pos != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s: %s (%s:%d)\n", diag.Severity, diag.Summary, diag.Detail, diag.Pos.Filename, diag.Pos.Line)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s: %s: %s\n", diag.Severity, diag.Summary, diag.Detail)
+}
@@ -14,12 +14,26 @@ import (
 // configuration loading.
 type Diagnostic struct {
 	Severity DiagSeverity `json:"severity"`
-	Summary  string       `json:"summary"`
-	Detail   string       `json:"detail,omitempty"`
+
+	// Code identifies the kind of problem, like "invalid-provider-reference",
+	// so that callers can filter diagnostics without matching summaries. The
+	// codes are stable even if the wording of the summary changes. Problems
+	// reported by the HCL parser itself, like syntax errors, all have the
+	// code "invalid-configuration".
+	Code string `json:"code,omitempty"`
+
+	Summary string `json:"summary"`
+	Detail  string `json:"detail,omitempty"`
 
 	// Pos is not populated for all diagnostics, but when populated should
 	// indicate a particular line that the described problem relates to.
+	// Its Range is the exact subject of the problem, when available.
 	Pos *SourcePos `json:"pos,omitempty"`
+
+	// Context is the position of the construct that contains the problem,
+	// such as a whole expression when Pos is just the part of it that is
+	// wrong. It is populated only for some diagnostics from the HCL parser.
+	Context *SourcePos `json:"context,omitempty"`
 }
 
 // Diagnostics represents a sequence of diagnostics. This is the type that
@@ -76,6 +90,22 @@ const DiagError DiagSeverity = 'E'
 // that did not prevent proper processing of the configuration.
 const DiagWarning DiagSeverity = 'W'
 
+// DiagHint indicates a suggested improvement to the configuration.
+const DiagHint DiagSeverity = 'H'
+
+func (s DiagSeverity) String() string {
+	switch s {
+	case DiagError:
+		return "error"
+	case DiagWarning:
+		return "warning"
+	case DiagHint:
+		return "hint"
+	default:
+		return "invalid"
+	}
+}
+
 // MarshalJSON is an implementation of encoding/json.Marshaler
 func (s DiagSeverity) MarshalJSON() ([]byte, error) {
 	switch s {
@@ -83,18 +113,28 @@ func (s DiagSeverity) MarshalJSON() ([]byte, error) {
 		return []byte(`"error"`), nil
 	case DiagWarning:
 		return []byte(`"warning"`), nil
+	case DiagHint:
+		return []byte(`"hint"`), nil
 	default:
 		return []byte(`"invalid"`), nil
 	}
 }
 
-func diagnosticsHCL(diags hcl.Diagnostics) Diagnostics {
+// diagnosticsHCL converts the given HCL diagnostics, using the codes that
+// the given options recorded for them. Any diagnostic without a recorded
+// code must have come from HCL itself.
+func diagnosticsHCL(diags hcl.Diagnostics, opts *decodeOptions) Diagnostics {
 	if len(diags) == 0 {
 		return nil
 	}
 	ret := make(Diagnostics, len(diags))
 	for i, diag := range diags {
+		code, ok := opts.codes[diag]
+		if !ok {
+			code = "invalid-configuration"
+		}
 		ret[i] = Diagnostic{
+			Code:    code,
 			Summary: diag.Summary,
 			Detail:  diag.Detail,
 		}
@@ -103,25 +143,36 @@ func diagnosticsHCL(diags hcl.Diagnostics) Diagnostics {
 			ret[i].Severity = DiagError
 		case hcl.DiagWarning:
 			ret[i].Severity = DiagWarning
+			if opts.hints[diag] {
+				ret[i].Severity = DiagHint
+			}
 		}
 		if diag.Subject != nil {
-			pos := sourcePosHCL(*diag.Subject)
+			pos := sourcePosHCL(*diag.Subject, opts)
 			ret[i].Pos = &pos
 		}
+		if diag.Context != nil {
+			pos := sourcePosHCL(*diag.Context, opts)
+			ret[i].Context = &pos
+		}
 	}
 	return ret
 }
 
-func diagnosticsError(err error) Diagnostics {
+func diagnosticsError(code string, err error) Diagnostics {
 	if err == nil {
 		return nil
 	}
 
 	if posErr, ok := err.(*legacyhclparser.PosError); ok {
-		pos := sourcePosLegacyHCL(posErr.Pos, "")
+		// Legacy syntax errors are only reported when the current parser
+		// has failed too, and then we return its diagnostics instead, so
+		// these never need a range.
+		pos := sourcePosLegacyHCL(posErr.Pos, "", newDecodeOptions(LoadOptions{}))
 		return Diagnostics{
 			Diagnostic{
 				Severity: DiagError,
+				Code:     code,
 				Summary:  posErr.Err.Error(),
 				Pos:      &pos,
 			},
@@ -131,11 +182,12 @@ func diagnosticsError(err error) Diagnostics {
 	return Diagnostics{
 		Diagnostic{
 			Severity: DiagError,
+			Code:     code,
 			Summary:  err.Error(),
 		},
 	}
 }
 
-func diagnosticsErrorf(format string, args ...interface{}) Diagnostics {
-	return diagnosticsError(fmt.Errorf(format, args...))
+func diagnosticsErrorf(code string, format string, args ...interface{}) Diagnostics {
+	return diagnosticsError(code, fmt.Errorf(format, args...))
 }
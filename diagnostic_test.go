@@ -0,0 +1,141 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestDiagnosticCodes(t *testing.T) {
+	type diagSummary struct {
+		Code    string
+		Summary string
+		Line    int
+	}
+	summarize := func(diags Diagnostics) []diagSummary {
+		var ret []diagSummary
+		for _, diag := range diags {
+			ret = append(ret, diagSummary{diag.Code, diag.Summary, diag.Pos.Line})
+		}
+		return ret
+	}
+
+	_, diags := LoadModule("testdata/override-merge")
+	want := []diagSummary{
+		{"missing-override-base", "Missing resource to override", 1},
+		{"missing-override-base", "Missing module call to override", 5},
+		{"missing-override-base", "Missing base local value definition to override", 10},
+		{"unsupported-override", `Cannot override "moved" blocks`, 13},
+	}
+	if diff := cmp.Diff(want, summarize(diags)); diff != "" {
+		t.Errorf("wrong diagnostics for override-merge\n%s", diff)
+	}
+
+	_, diags = LoadModule("testdata/syntax-error")
+	want = []diagSummary{
+		{"invalid-configuration", "Argument or block definition required", 1},
+	}
+	if diff := cmp.Diff(want, summarize(diags)); diff != "" {
+		t.Errorf("wrong diagnostics for syntax-error\n%s", diff)
+	}
+}
+
+func TestDecodeRequiredProvidersBlockCodes(t *testing.T) {
+	tests := map[string]string{
+		`{ (1) = "hashicorp/aws" }`: "invalid-provider-requirement",
+		`{ version = 5 }`:           "invalid-provider-version-type",
+		`{ source = ["aws"] }`:      "invalid-provider-source-type",
+		`["aws"]`:                   "invalid-required-providers",
+	}
+	for src, want := range tests {
+		t.Run(src, func(t *testing.T) {
+			config := "required_providers {\n  aws = " + src + "\n}\n"
+			file, diags := hclsyntax.ParseConfig([]byte(config), "test.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", diags.Error())
+			}
+			content, _ := file.Body.Content(terraformBlockSchema)
+			opts := newDecodeOptions(LoadOptions{})
+			_, diags = decodeRequiredProvidersBlock(content.Blocks[0], opts)
+			got := diagnosticsHCL(diags, opts)
+			if len(got) != 1 || got[0].Code != want {
+				t.Errorf("wrong diagnostics %#v; want one with code %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsHCLCodes(t *testing.T) {
+	opts := newDecodeOptions(LoadOptions{})
+	ours := opts.diagnostic("invalid-address", &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid address",
+	})
+	hint := opts.hint("legacy-type-syntax", &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  "Legacy type constraint syntax",
+	})
+	// A diagnostic from HCL itself keeps the default code, even if it has
+	// the same summary as one of ours.
+	theirs := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid address",
+	}
+
+	got := diagnosticsHCL(hcl.Diagnostics{ours, hint, theirs}, opts)
+	want := Diagnostics{
+		{Severity: DiagError, Code: "invalid-address", Summary: "Invalid address"},
+		{Severity: DiagHint, Code: "legacy-type-syntax", Summary: "Legacy type constraint syntax"},
+		{Severity: DiagError, Code: "invalid-configuration", Summary: "Invalid address"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+}
+
+func TestDiagnosticContext(t *testing.T) {
+	_, diags := LoadModuleWithOptions(NewOsFs(), "testdata/type-errors", LoadOptions{SourceRanges: true})
+	for _, diag := range diags {
+		if diag.Context == nil {
+			continue
+		}
+		if diag.Context.Range == nil || diag.Pos.Range == nil {
+			t.Fatalf("missing ranges for diagnostic %q", diag.Summary)
+		}
+		if diag.Context.Range.Start.Byte > diag.Pos.Range.Start.Byte || diag.Context.Range.End.Byte < diag.Pos.Range.End.Byte {
+			t.Errorf("context %#v of diagnostic %q doesn't contain its subject %#v", diag.Context.Range, diag.Summary, diag.Pos.Range)
+		}
+		return
+	}
+	t.Fatal("no diagnostic with a context")
+}
+
+func TestDiagSeverityJSON(t *testing.T) {
+	tests := map[DiagSeverity]string{
+		DiagError:   `"error"`,
+		DiagWarning: `"warning"`,
+		DiagHint:    `"hint"`,
+	}
+	for severity, want := range tests {
+		got, err := json.Marshal(severity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("wrong JSON for %s: got %s, want %s", severity, got, want)
+		}
+	}
+
+	diags := Diagnostics{
+		{Severity: DiagHint, Summary: "hint"},
+	}
+	if diags.HasErrors() {
+		t.Error("hint diagnostics must not count as errors")
+	}
+}
@@ -9,8 +9,8 @@
 // This packge focuses on describing top-level objects only, and in particular
 // does not attempt any sort of processing that would require access to plugins.
 // Currently it allows callers to extract high-level information about
-// variables, outputs, resource blocks, provider dependencies, and Terraform
-// Core dependencies.
+// variables, local values, outputs, resource blocks, provider dependencies,
+// and Terraform Core dependencies.
 //
 // This package only works at the level of single modules. A full configuration
 // is a tree of potentially several modules, some of which may be references
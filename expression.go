@@ -0,0 +1,92 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Expression represents an arbitrary expression from the configuration,
+// such as the value assigned to a local value.
+type Expression struct {
+	// Expr is the underlying HCL expression, for callers that wish to do
+	// their own more detailed analysis.
+	Expr hcl.Expression `json:"-"`
+
+	// Source is the raw source text of the expression as written in the
+	// configuration file.
+	Source string `json:"source"`
+
+	// Value is an approximate representation of the value of the
+	// expression in the native Go type system, populated only if the
+	// expression is constant. See Variable.Default for more information on
+	// this representation.
+	Value interface{} `json:"value,omitempty"`
+
+	// References are the references the expression makes to other objects
+	// in the module.
+	References []Reference `json:"references,omitempty"`
+}
+
+// newExpression constructs an Expression from the given HCL expression,
+// which must belong to the given file.
+func newExpression(expr hcl.Expression, file *hcl.File, opts *decodeOptions) *Expression {
+	ret := &Expression{
+		Expr:       expr,
+		Source:     string(expr.Range().SliceBytes(file.Bytes)),
+		References: referencesForExpr(expr, nil, opts),
+	}
+
+	// An expression without any variables might still fail evaluation
+	// if it includes function calls, in which case it's not constant as
+	// far as we're concerned.
+	if len(expr.Variables()) == 0 {
+		val, valDiags := expr.Value(nil)
+		if !valDiags.HasErrors() && val.IsWhollyKnown() {
+			ret.Value = ctyValueToGo(val)
+		}
+	}
+
+	return ret
+}
+
+// ctyValueToGo converts the given known cty value into an approximately
+// equivalent plain Go interface{} value, using its JSON encoding, so that
+// callers don't need to deal with cty.
+func ctyValueToGo(val cty.Value) interface{} {
+	valJSON, err := ctyjson.Marshal(val, val.Type())
+	if err != nil {
+		// Should never happen, since all possible known
+		// values have a JSON mapping.
+		panic(fmt.Errorf("failed to serialize value as JSON: %s", err))
+	}
+	var ret interface{}
+	err = json.Unmarshal(valJSON, &ret)
+	if err != nil {
+		// Again should never happen, because valJSON is
+		// guaranteed valid by ctyjson.Marshal.
+		panic(fmt.Errorf("failed to re-parse value from JSON: %s", err))
+	}
+	return ret
+}
+
+// goValueToCty converts the given plain Go value into an approximately
+// equivalent cty value, using its JSON encoding. This is the inverse of
+// ctyValueToGo, used for values that the legacy loader decodes without cty.
+func goValueToCty(v interface{}) (cty.Value, error) {
+	valJSON, err := json.Marshal(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	ty, err := ctyjson.ImpliedType(valJSON)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(valJSON, ty)
+}
@@ -2,6 +2,7 @@ module github.com/yardbirdsax/terraparse
 
 require (
 	github.com/google/go-cmp v0.3.0
+	github.com/hashicorp/go-version v1.6.0
 	github.com/hashicorp/hcl v0.0.0-20170504190234-a4b07c25de5f
 	github.com/hashicorp/hcl/v2 v2.0.0
 	github.com/spf13/pflag v1.0.3
@@ -0,0 +1,473 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Graph is a dependency graph of the objects declared in a single module,
+// built from the references between them.
+//
+// Each edge points from an object to one of the objects it depends on, in
+// the same direction as the edges produced by "terraform graph".
+type Graph struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+
+	nodes map[string]*GraphNode
+	deps  map[string][]*GraphEdge
+	users map[string][]*GraphEdge
+}
+
+// GraphNode is a single object in a Graph.
+type GraphNode struct {
+	// Address is the address used to refer to the object within its
+	// module, like "var.foo", "aws_instance.web" or "module.network".
+	// Outputs, which can't be referred to, use addresses like "output.id".
+	Address string        `json:"address"`
+	Kind    GraphNodeKind `json:"kind"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// GraphNodeKind represents the kind of object represented by a GraphNode.
+type GraphNodeKind rune
+
+const InvalidGraphNodeKind GraphNodeKind = 0
+const VariableGraphNodeKind GraphNodeKind = 'V'
+const LocalGraphNodeKind GraphNodeKind = 'L'
+const ManagedResourceGraphNodeKind GraphNodeKind = 'R'
+const DataResourceGraphNodeKind GraphNodeKind = 'D'
+const ModuleCallGraphNodeKind GraphNodeKind = 'M'
+const OutputGraphNodeKind GraphNodeKind = 'O'
+
+func (k GraphNodeKind) String() string {
+	switch k {
+	case VariableGraphNodeKind:
+		return "variable"
+	case LocalGraphNodeKind:
+		return "local"
+	case ManagedResourceGraphNodeKind:
+		return "resource"
+	case DataResourceGraphNodeKind:
+		return "data"
+	case ModuleCallGraphNodeKind:
+		return "module"
+	case OutputGraphNodeKind:
+		return "output"
+	default:
+		return ""
+	}
+}
+
+// MarshalJSON implements encoding/json.Marshaler.
+func (k GraphNodeKind) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(k.String())), nil
+}
+
+// GraphEdge is a dependency of one object on another in a Graph.
+type GraphEdge struct {
+	// From is the address of the dependent object and To is the address of
+	// the object it depends on.
+	From string        `json:"from"`
+	To   string        `json:"to"`
+	Kind GraphEdgeKind `json:"kind"`
+
+	// Pos is the position of the first reference that created the edge.
+	Pos SourcePos `json:"pos"`
+}
+
+// GraphEdgeKind represents the reason for a dependency in a Graph.
+type GraphEdgeKind rune
+
+const InvalidGraphEdgeKind GraphEdgeKind = 0
+
+// ReferenceGraphEdgeKind is the kind of edges created by references in
+// expressions.
+const ReferenceGraphEdgeKind GraphEdgeKind = 'R'
+
+// DependsOnGraphEdgeKind is the kind of edges created by explicit
+// dependencies in a depends_on argument.
+const DependsOnGraphEdgeKind GraphEdgeKind = 'D'
+
+func (k GraphEdgeKind) String() string {
+	switch k {
+	case ReferenceGraphEdgeKind:
+		return "reference"
+	case DependsOnGraphEdgeKind:
+		return "depends_on"
+	default:
+		return ""
+	}
+}
+
+// MarshalJSON implements encoding/json.Marshaler.
+func (k GraphEdgeKind) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(k.String())), nil
+}
+
+// Graph builds the dependency graph for the objects declared in the module.
+//
+// References to objects that are not declared in the module, and to
+// objects that aren't represented in the graph such as count.index, are
+// ignored. The returned diagnostics describe any dependency cycles.
+//
+// Modules loaded by the legacy loader have no expressions to analyze, so
+// their graphs have nodes but no edges.
+func (m *Module) Graph() (*Graph, Diagnostics) {
+	g := &Graph{
+		nodes: make(map[string]*GraphNode),
+		deps:  make(map[string][]*GraphEdge),
+		users: make(map[string][]*GraphEdge),
+	}
+
+	for _, name := range sortedKeys(m.Variables) {
+		g.addNode("var."+name, VariableGraphNodeKind, m.Variables[name].Pos)
+	}
+	for _, name := range sortedKeys(m.Locals) {
+		g.addNode("local."+name, LocalGraphNodeKind, m.Locals[name].Pos)
+	}
+	for _, key := range sortedKeys(m.ManagedResources) {
+		g.addNode(key, ManagedResourceGraphNodeKind, m.ManagedResources[key].Pos)
+	}
+	for _, key := range sortedKeys(m.DataResources) {
+		g.addNode(key, DataResourceGraphNodeKind, m.DataResources[key].Pos)
+	}
+	for _, name := range sortedKeys(m.ModuleCalls) {
+		g.addNode("module."+name, ModuleCallGraphNodeKind, m.ModuleCalls[name].Pos)
+	}
+	for _, name := range sortedKeys(m.Outputs) {
+		g.addNode("output."+name, OutputGraphNodeKind, m.Outputs[name].Pos)
+	}
+
+	for _, name := range sortedKeys(m.Variables) {
+		for _, rule := range m.Variables[name].Validations {
+			if rule.Condition == nil {
+				continue
+			}
+			// Validation rules always refer to the variable itself, which
+			// is not a dependency.
+			var refs []Reference
+			for _, ref := range rule.Condition.References {
+				if ref.Subject != "var."+name {
+					refs = append(refs, ref)
+				}
+			}
+			g.addReferences("var."+name, ReferenceGraphEdgeKind, refs)
+		}
+	}
+	for _, name := range sortedKeys(m.Locals) {
+		g.addExpressionReferences("local."+name, m.Locals[name].Expression)
+	}
+	for _, key := range sortedKeys(m.ManagedResources) {
+		g.addResourceReferences(key, m.ManagedResources[key])
+	}
+	for _, key := range sortedKeys(m.DataResources) {
+		g.addResourceReferences(key, m.DataResources[key])
+	}
+	for _, name := range sortedKeys(m.ModuleCalls) {
+		mc := m.ModuleCalls[name]
+		g.addExpressionReferences("module."+name, mc.Count, mc.ForEach)
+		g.addAttributeReferences("module."+name, mc.Attributes)
+		g.addReferences("module."+name, DependsOnGraphEdgeKind, mc.DependsOn)
+	}
+	for _, name := range sortedKeys(m.Outputs) {
+		o := m.Outputs[name]
+		g.addExpressionReferences("output."+name, o.Value)
+		g.addCheckRuleReferences("output."+name, o.Preconditions)
+		g.addReferences("output."+name, DependsOnGraphEdgeKind, o.DependsOn)
+	}
+
+	return g, g.cycleDiagnostics()
+}
+
+func (g *Graph) addNode(addr string, kind GraphNodeKind, pos SourcePos) {
+	node := &GraphNode{
+		Address: addr,
+		Kind:    kind,
+		Pos:     pos,
+	}
+	g.Nodes = append(g.Nodes, node)
+	g.nodes[addr] = node
+}
+
+func (g *Graph) addResourceReferences(from string, r *Resource) {
+	g.addExpressionReferences(from, r.Count, r.ForEach)
+	g.addAttributeReferences(from, r.Attributes)
+	g.addBlockReferences(from, r.Blocks)
+	if lc := r.Lifecycle; lc != nil {
+		g.addExpressionReferences(from, lc.ReplaceTriggeredBy...)
+		g.addCheckRuleReferences(from, lc.Preconditions)
+		g.addCheckRuleReferences(from, lc.Postconditions)
+	}
+	g.addReferences(from, DependsOnGraphEdgeKind, r.DependsOn)
+}
+
+func (g *Graph) addExpressionReferences(from string, exprs ...*Expression) {
+	for _, expr := range exprs {
+		if expr != nil {
+			g.addReferences(from, ReferenceGraphEdgeKind, expr.References)
+		}
+	}
+}
+
+func (g *Graph) addAttributeReferences(from string, attrs Attributes) {
+	for _, name := range sortedKeys(attrs) {
+		g.addReferences(from, ReferenceGraphEdgeKind, attrs[name].References)
+	}
+}
+
+func (g *Graph) addCheckRuleReferences(from string, rules []*CheckRule) {
+	for _, rule := range rules {
+		g.addExpressionReferences(from, rule.Condition)
+	}
+}
+
+func (g *Graph) addBlockReferences(from string, blocks []*Block) {
+	for _, block := range blocks {
+		g.addAttributeReferences(from, block.Attributes)
+		g.addBlockReferences(from, block.Blocks)
+	}
+}
+
+func (g *Graph) addReferences(from string, kind GraphEdgeKind, refs []Reference) {
+	for _, ref := range refs {
+		to := graphNodeAddress(ref)
+		if to == "" || g.nodes[to] == nil {
+			continue
+		}
+		duplicate := false
+		for _, edge := range g.deps[from] {
+			if edge.To == to && edge.Kind == kind {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		edge := &GraphEdge{
+			From: from,
+			To:   to,
+			Kind: kind,
+			Pos:  ref.Pos,
+		}
+		g.Edges = append(g.Edges, edge)
+		g.deps[from] = append(g.deps[from], edge)
+		g.users[to] = append(g.users[to], edge)
+	}
+}
+
+// graphNodeAddress returns the address of the graph node that the given
+// reference refers to, or an empty string if the referenced object is not
+// represented in the graph.
+func graphNodeAddress(ref Reference) string {
+	switch ref.Kind {
+	case VariableReferenceKind, LocalReferenceKind, ManagedResourceReferenceKind, DataResourceReferenceKind:
+		return ref.Subject
+	case ModuleCallReferenceKind:
+		// The subject may include an output name, but the graph represents
+		// only the module call as a whole.
+		parts := strings.SplitN(ref.Subject, ".", 3)
+		return parts[0] + "." + parts[1]
+	default:
+		return ""
+	}
+}
+
+// Node returns the node with the given address, or nil if there is no such
+// node in the graph.
+func (g *Graph) Node(addr string) *GraphNode {
+	return g.nodes[addr]
+}
+
+// Dependencies returns the sorted addresses of the objects that the object
+// with the given address depends on directly.
+func (g *Graph) Dependencies(addr string) []string {
+	seen := make(map[string]bool)
+	for _, edge := range g.deps[addr] {
+		seen[edge.To] = true
+	}
+	return sortedKeys(seen)
+}
+
+// Dependents returns the sorted addresses of the objects that depend
+// directly on the object with the given address.
+func (g *Graph) Dependents(addr string) []string {
+	seen := make(map[string]bool)
+	for _, edge := range g.users[addr] {
+		seen[edge.From] = true
+	}
+	return sortedKeys(seen)
+}
+
+// TransitiveDependents returns the sorted addresses of all of the objects
+// that depend directly or indirectly on the object with the given address,
+// which are the objects that may be affected by a change to that object.
+func (g *Graph) TransitiveDependents(addr string) []string {
+	seen := make(map[string]bool)
+	queue := []string{addr}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.users[current] {
+			if seen[edge.From] {
+				continue
+			}
+			seen[edge.From] = true
+			queue = append(queue, edge.From)
+		}
+	}
+	delete(seen, addr)
+	return sortedKeys(seen)
+}
+
+// TopologicalOrder returns the nodes of the graph ordered so that each node
+// appears after all of the nodes it depends on. Nodes that have no ordering
+// constraint between them are ordered by address.
+//
+// If the graph contains cycles then there is no such ordering, so the result
+// is nil and the diagnostics describe the cycles.
+func (g *Graph) TopologicalOrder() ([]*GraphNode, Diagnostics) {
+	if diags := g.cycleDiagnostics(); diags.HasErrors() {
+		return nil, diags
+	}
+
+	pending := make(map[string]int, len(g.Nodes))
+	for _, node := range g.Nodes {
+		pending[node.Address] = len(g.Dependencies(node.Address))
+	}
+
+	var ready []string
+	for addr, count := range pending {
+		if count == 0 {
+			ready = append(ready, addr)
+		}
+	}
+	sort.Strings(ready)
+
+	ret := make([]*GraphNode, 0, len(g.Nodes))
+	for len(ready) > 0 {
+		addr := ready[0]
+		ready = ready[1:]
+		ret = append(ret, g.nodes[addr])
+
+		var next []string
+		for _, user := range g.Dependents(addr) {
+			pending[user]--
+			if pending[user] == 0 {
+				next = append(next, user)
+			}
+		}
+		ready = append(ready, next...)
+		sort.Strings(ready)
+	}
+	return ret, nil
+}
+
+// cycleDiagnostics returns an error diagnostic for each dependency cycle in
+// the graph, found using Tarjan's strongly connected components algorithm.
+func (g *Graph) cycleDiagnostics() Diagnostics {
+	var diags Diagnostics
+
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var visit func(addr string)
+	visit = func(addr string) {
+		index[addr] = len(index)
+		lowlink[addr] = index[addr]
+		stack = append(stack, addr)
+		onStack[addr] = true
+
+		selfLoop := false
+		for _, dep := range g.Dependencies(addr) {
+			if dep == addr {
+				selfLoop = true
+			}
+			if _, visited := index[dep]; !visited {
+				visit(dep)
+				if lowlink[dep] < lowlink[addr] {
+					lowlink[addr] = lowlink[dep]
+				}
+			} else if onStack[dep] && index[dep] < lowlink[addr] {
+				lowlink[addr] = index[dep]
+			}
+		}
+
+		if lowlink[addr] != index[addr] {
+			return
+		}
+		var members []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			members = append(members, top)
+			if top == addr {
+				break
+			}
+		}
+		if len(members) == 1 && !selfLoop {
+			return
+		}
+		sort.Strings(members)
+		pos := g.nodes[members[0]].Pos
+		diags = append(diags, Diagnostic{
+			Severity: DiagError,
+			Code:     "dependency-cycle",
+			Summary:  "Dependency cycle",
+			Detail:   fmt.Sprintf("The following objects depend on each other, so there is no valid order in which to evaluate them: %s.", strings.Join(members, ", ")),
+			Pos:      &pos,
+		})
+	}
+
+	for _, node := range g.Nodes {
+		if _, visited := index[node.Address]; !visited {
+			visit(node.Address)
+		}
+	}
+	return diags
+}
+
+// WriteDOT writes a representation of the graph in the Graphviz DOT
+// language to the given writer.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+	b.WriteString("  compound = \"true\"\n")
+	b.WriteString("  newrank = \"true\"\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s]\n", strconv.Quote(node.Address), strconv.Quote(node.Address), dotNodeShape(node.Kind))
+	}
+	for _, edge := range g.Edges {
+		if edge.Kind == DependsOnGraphEdgeKind {
+			fmt.Fprintf(&b, "  %s -> %s [style=dashed]\n", strconv.Quote(edge.From), strconv.Quote(edge.To))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s -> %s\n", strconv.Quote(edge.From), strconv.Quote(edge.To))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotNodeShape(kind GraphNodeKind) string {
+	switch kind {
+	case VariableGraphNodeKind, OutputGraphNodeKind:
+		return "note"
+	case LocalGraphNodeKind:
+		return "ellipse"
+	case ModuleCallGraphNodeKind:
+		return "component"
+	default:
+		return "box"
+	}
+}
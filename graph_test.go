@@ -0,0 +1,132 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleGraph(t *testing.T) {
+	mod, diags := LoadModule("testdata/graph")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors loading module: %s", diags.Error())
+	}
+	g, diags := mod.Graph()
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+
+	var gotEdges []string
+	for _, edge := range g.Edges {
+		gotEdges = append(gotEdges, edge.From+" -> "+edge.To+" ("+edge.Kind.String()+")")
+	}
+	wantEdges := []string{
+		"local.name -> var.environment (reference)",
+		"local.tags -> local.name (reference)",
+		"local.tags -> var.environment (reference)",
+		"aws_instance.web -> data.aws_ami.ubuntu (reference)",
+		"aws_instance.web -> aws_subnet.public (reference)",
+		"aws_instance.web -> module.dns (depends_on)",
+		"aws_subnet.public -> var.cidr_block (reference)",
+		"aws_subnet.public -> aws_vpc.main (reference)",
+		"aws_vpc.main -> var.cidr_block (reference)",
+		"aws_vpc.main -> local.tags (reference)",
+		"module.dns -> aws_vpc.main (reference)",
+		"output.instance_ip -> aws_instance.web (reference)",
+		"output.zone_id -> module.dns (reference)",
+	}
+	if diff := cmp.Diff(wantEdges, gotEdges); diff != "" {
+		t.Errorf("wrong edges\n%s", diff)
+	}
+
+	wantDependents := []string{
+		"aws_instance.web",
+		"aws_subnet.public",
+		"aws_vpc.main",
+		"local.name",
+		"local.tags",
+		"module.dns",
+		"output.instance_ip",
+		"output.zone_id",
+	}
+	if diff := cmp.Diff(wantDependents, g.TransitiveDependents("var.environment")); diff != "" {
+		t.Errorf("wrong transitive dependents\n%s", diff)
+	}
+
+	order, diags := g.TopologicalOrder()
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+	var gotOrder []string
+	for _, node := range order {
+		gotOrder = append(gotOrder, node.Address)
+	}
+	wantOrder := []string{
+		"data.aws_ami.ubuntu",
+		"var.cidr_block",
+		"var.environment",
+		"local.name",
+		"local.tags",
+		"aws_vpc.main",
+		"aws_subnet.public",
+		"module.dns",
+		"aws_instance.web",
+		"output.instance_ip",
+		"output.zone_id",
+	}
+	if diff := cmp.Diff(wantOrder, gotOrder); diff != "" {
+		t.Errorf("wrong topological order\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatalf("unexpected error writing DOT: %s", err)
+	}
+	for _, want := range []string{
+		`"var.environment" [label="var.environment", shape=note]`,
+		`"aws_instance.web" -> "module.dns" [style=dashed]`,
+		`"output.zone_id" -> "module.dns"`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("DOT output does not contain %q\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestModuleGraphCycles(t *testing.T) {
+	mod, diags := LoadModule("testdata/graph-cycle")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors loading module: %s", diags.Error())
+	}
+	g, diags := mod.Graph()
+
+	type diagSummary struct {
+		Severity DiagSeverity
+		Summary  string
+		Line     int
+	}
+	var got []diagSummary
+	for _, diag := range diags {
+		got = append(got, diagSummary{diag.Severity, diag.Summary, diag.Pos.Line})
+	}
+	want := []diagSummary{
+		{DiagError, "Dependency cycle", 2},
+		{DiagError, "Dependency cycle", 6},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+
+	order, diags := g.TopologicalOrder()
+	if order != nil {
+		t.Errorf("unexpected topological order for cyclic graph")
+	}
+	if !diags.HasErrors() {
+		t.Errorf("no errors for cyclic graph")
+	}
+}
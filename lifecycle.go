@@ -0,0 +1,94 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
+// Lifecycle represents the "lifecycle" block within a resource, which
+// customizes how Terraform manages the resource.
+type Lifecycle struct {
+	CreateBeforeDestroy bool `json:"create_before_destroy,omitempty"`
+	PreventDestroy      bool `json:"prevent_destroy,omitempty"`
+
+	// IgnoreChanges are the attribute paths given in the ignore_changes
+	// argument, as written in configuration, like "tags" or
+	// `tags["Name"]`. IgnoreAllChanges is set instead if the argument is the
+	// keyword "all".
+	IgnoreChanges    []string `json:"ignore_changes,omitempty"`
+	IgnoreAllChanges bool     `json:"ignore_all_changes,omitempty"`
+
+	// ReplaceTriggeredBy are the expressions given in the
+	// replace_triggered_by argument.
+	ReplaceTriggeredBy []*Expression `json:"replace_triggered_by,omitempty"`
+
+	Preconditions  []*CheckRule `json:"preconditions,omitempty"`
+	Postconditions []*CheckRule `json:"postconditions,omitempty"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// decodeLifecycle decodes a lifecycle block from the given file.
+func decodeLifecycle(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*Lifecycle, hcl.Diagnostics) {
+	content, diags := block.Body.Content(lifecycleSchema)
+
+	lc := &Lifecycle{
+		Pos: sourcePosHCL(block.DefRange, opts),
+	}
+
+	if attr, defined := content.Attributes["create_before_destroy"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &lc.CreateBeforeDestroy)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, defined := content.Attributes["prevent_destroy"]; defined {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &lc.PreventDestroy)
+		diags = append(diags, valDiags...)
+	}
+
+	if attr, defined := content.Attributes["ignore_changes"]; defined {
+		if hcl.ExprAsKeyword(attr.Expr) == "all" {
+			lc.IgnoreAllChanges = true
+		} else {
+			exprs, listDiags := hcl.ExprList(attr.Expr)
+			diags = append(diags, listDiags...)
+			for _, expr := range exprs {
+				// Older versions of Terraform expected quoted strings here,
+				// including "*" to ignore all changes.
+				var path string
+				if valDiags := gohcl.DecodeExpression(expr, nil, &path); valDiags.HasErrors() {
+					path = string(expr.Range().SliceBytes(file.Bytes))
+				}
+				if path == "*" {
+					lc.IgnoreAllChanges = true
+					continue
+				}
+				lc.IgnoreChanges = append(lc.IgnoreChanges, path)
+			}
+		}
+	}
+
+	if attr, defined := content.Attributes["replace_triggered_by"]; defined {
+		exprs, listDiags := hcl.ExprList(attr.Expr)
+		diags = append(diags, listDiags...)
+		for _, expr := range exprs {
+			lc.ReplaceTriggeredBy = append(lc.ReplaceTriggeredBy, newExpression(expr, file, opts))
+		}
+	}
+
+	for _, block := range content.Blocks {
+		cr, crDiags := decodeCheckRule(block, file, opts)
+		diags = append(diags, crDiags...)
+		switch block.Type {
+		case "precondition":
+			lc.Preconditions = append(lc.Preconditions, cr)
+		case "postcondition":
+			lc.Postconditions = append(lc.Postconditions, cr)
+		}
+	}
+
+	return lc, diags
+}
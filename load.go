@@ -6,6 +6,7 @@ package terraparse
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
@@ -20,24 +21,81 @@ func LoadModule(dir string) (*Module, Diagnostics) {
 // LoadModuleFromFilesystem reads the directory at the given path
 // in the given FS and attempts to interpret it as a Terraform module
 func LoadModuleFromFilesystem(fs FS, dir string) (*Module, Diagnostics) {
+	return LoadModuleWithOptions(fs, dir, LoadOptions{})
+}
+
+// LoadOptions customizes how LoadModuleWithOptions loads a module.
+type LoadOptions struct {
+	// SourceRanges requests the exact source range of each element and
+	// diagnostic, in the Range field of their SourcePos.
+	SourceRanges bool
+}
+
+// LoadModuleWithOptions reads the directory at the given path in the given
+// FS and attempts to interpret it as a Terraform module, customized by the
+// given options.
+func LoadModuleWithOptions(fs FS, dir string, opts LoadOptions) (*Module, Diagnostics) {
+	return loadModuleFromFilesystem(fs, dir, newDecodeOptions(opts))
+}
+
+// decodeOptions are the options for decoding the files of a module, along
+// with the codes of the HCL diagnostics that decoding them produces. An
+// hcl.Diagnostic has no place to record a code of its own, so we record
+// each code here when creating the diagnostic and look it up again when
+// converting the diagnostic with diagnosticsHCL.
+type decodeOptions struct {
+	LoadOptions
+
+	codes map[*hcl.Diagnostic]string
+	hints map[*hcl.Diagnostic]bool
+}
+
+func newDecodeOptions(opts LoadOptions) *decodeOptions {
+	return &decodeOptions{
+		LoadOptions: opts,
+		codes:       make(map[*hcl.Diagnostic]string),
+		hints:       make(map[*hcl.Diagnostic]bool),
+	}
+}
+
+// diagnostic records the given code for the given diagnostic, returning
+// the diagnostic.
+func (o *decodeOptions) diagnostic(code string, diag *hcl.Diagnostic) *hcl.Diagnostic {
+	o.codes[diag] = code
+	return diag
+}
+
+// hint records the given code for the given diagnostic and marks it as a
+// hint rather than a warning, returning the diagnostic. HCL has no severity
+// for hints, so the diagnostic itself must be a warning.
+func (o *decodeOptions) hint(code string, diag *hcl.Diagnostic) *hcl.Diagnostic {
+	o.hints[diag] = true
+	return o.diagnostic(code, diag)
+}
+
+func loadModuleFromFilesystem(fs FS, dir string, opts *decodeOptions) (*Module, Diagnostics) {
 	// For broad compatibility here we actually have two separate loader
 	// codepaths. The main one uses the new HCL parser and API and is intended
 	// for configurations from Terraform 0.12 onwards (though will work for
 	// many older configurations too), but we'll also fall back on one that
 	// uses the _old_ HCL implementation so we can deal with some edge-cases
 	// that are not valid in new HCL.
-
-	module, diags := loadModule(fs, dir)
+	//
+	// Errors from merging override files come from the configuration's
+	// meaning rather than its syntax, so the legacy parser can't fare any
+	// better with them.
+	module, diags, overrideDiags := loadModule(fs, dir, opts)
 	if diags.HasErrors() {
 		// Try using the legacy HCL parser and see if we fare better.
-		legacyModule, legacyDiags := loadModuleLegacyHCL(fs, dir)
+		legacyModule, legacyDiags := loadModuleLegacyHCL(fs, dir, opts)
 		if !legacyDiags.HasErrors() {
-			legacyModule.init(legacyDiags)
+			legacyDiags = legacyModule.init(legacyDiags)
 			return legacyModule, legacyDiags
 		}
 	}
 
-	module.init(diags)
+	diags = append(diags, overrideDiags...)
+	diags = module.init(diags)
 	return module, diags
 }
 
@@ -51,11 +109,14 @@ func IsModuleDir(dir string) bool {
 // Terraform configuration files. This allows the caller to decide
 // how to handle directories that do not have tf files.
 func IsModuleDirOnFilesystem(fs FS, dir string) bool {
-	primaryPaths, _ := dirFiles(fs, dir)
+	primaryPaths, _ := dirFiles(fs, dir, newDecodeOptions(LoadOptions{}))
 	return len(primaryPaths) == 0
 }
 
-func (m *Module) init(diags Diagnostics) {
+// init performs any post-processing of the module that depends on the
+// content of all of its files, returning the given diagnostics along with
+// any additional diagnostics produced by that processing.
+func (m *Module) init(diags Diagnostics) Diagnostics {
 	// Fill in any additional provider requirements that are implied by
 	// resource configurations, to avoid the caller from needing to apply
 	// this logic itself. Implied requirements don't have version constraints,
@@ -71,21 +132,64 @@ func (m *Module) init(diags Diagnostics) {
 			m.RequiredProviders[r.Provider.Name] = &ProviderRequirement{}
 		}
 	}
+	for _, c := range m.Checks {
+		if r := c.DataResource; r != nil {
+			if _, exists := m.RequiredProviders[r.Provider.Name]; !exists {
+				m.RequiredProviders[r.Provider.Name] = &ProviderRequirement{}
+			}
+		}
+	}
+
+	if m.Backend != nil && m.Cloud != nil {
+		pos := m.Cloud.Pos
+		diags = append(diags, Diagnostic{
+			Severity: DiagError,
+			Code:     "backend-and-cloud",
+			Summary:  "Both a backend and a cloud configuration are present",
+			Detail:   fmt.Sprintf("A module may declare either a backend or a cloud block, but not both. The backend is configured at %s:%d.", m.Backend.Pos.Filename, m.Backend.Pos.Line),
+			Pos:      &pos,
+		})
+	}
+
+	// Module calls may have been changed by override files, so we can only
+	// interpret their addresses once all of the files are loaded.
+	for _, name := range sortedKeys(m.ModuleCalls) {
+		diags = append(diags, m.ModuleCalls[name].decodeAddrs()...)
+	}
+
+	for _, name := range sortedKeys(m.Variables) {
+		diags = append(diags, m.Variables[name].convertDefault()...)
+	}
+
+	// Refactoring blocks can refer to objects declared in any file.
+	diags = append(diags, m.validateRefactoring()...)
 
 	// We redundantly also reference the diagnostics from inside the module
 	// object, primarily so that we can easily included in JSON-serialized
 	// versions of the module object.
 	m.Diagnostics = diags
+	return diags
+}
+
+// sortedKeys returns the keys of the given map in lexical order, for
+// producing deterministic results from map iteration.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
-func dirFiles(fs FS, dir string) (primary []string, diags hcl.Diagnostics) {
+func dirFiles(fs FS, dir string, opts *decodeOptions) (primary []string, diags hcl.Diagnostics) {
 	infos, err := fs.ReadDir(dir)
 	if err != nil {
-		diags = append(diags, &hcl.Diagnostic{
+		diags = append(diags, opts.diagnostic("read-module-directory-failed", &hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Failed to read module directory",
 			Detail:   fmt.Sprintf("Module directory %s does not exist or cannot be read.", dir),
-		})
+		}))
 		return
 	}
 
@@ -102,11 +206,8 @@ func dirFiles(fs FS, dir string) (primary []string, diags hcl.Diagnostics) {
 			continue
 		}
 
-		baseName := name[:len(name)-len(ext)] // strip extension
-		isOverride := baseName == "override" || strings.HasSuffix(baseName, "_override")
-
 		fullPath := filepath.Join(dir, name)
-		if isOverride {
+		if isOverrideFile(name) {
 			override = append(override, fullPath)
 		} else {
 			primary = append(primary, fullPath)
@@ -120,6 +221,14 @@ func dirFiles(fs FS, dir string) (primary []string, diags hcl.Diagnostics) {
 	return
 }
 
+// isOverrideFile returns true if the given path is an override file, whose
+// contents are merged into the configuration from the other files.
+func isOverrideFile(path string) bool {
+	name := filepath.Base(path)
+	baseName := name[:len(name)-len(fileExt(name))] // strip extension
+	return baseName == "override" || strings.HasSuffix(baseName, "_override")
+}
+
 // fileExt returns the Terraform configuration extension of the given
 // path, or a blank string if it is not a recognized extension.
 func fileExt(path string) string {
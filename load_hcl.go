@@ -4,22 +4,22 @@
 package terraparse
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
-	"github.com/hashicorp/hcl/v2/hclsyntax"
-
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclparse"
-	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
-func loadModule(fs FS, dir string) (*Module, Diagnostics) {
+// loadModule loads the module in the given directory, returning the
+// diagnostics from merging override files separately from all of the
+// others.
+func loadModule(fs FS, dir string, opts *decodeOptions) (*Module, Diagnostics, Diagnostics) {
 	mod := NewModule(dir)
-	primaryPaths, diags := dirFiles(fs, dir)
+	primaryPaths, diags := dirFiles(fs, dir, opts)
 
+	var overrideDiags hcl.Diagnostics
 	parser := hclparse.NewParser()
 
 	for _, filename := range primaryPaths {
@@ -28,11 +28,11 @@ func loadModule(fs FS, dir string) (*Module, Diagnostics) {
 
 		b, err := fs.ReadFile(filename)
 		if err != nil {
-			diags = append(diags, &hcl.Diagnostic{
+			diags = append(diags, opts.diagnostic("read-file-failed", &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Failed to read file",
 				Detail:   fmt.Sprintf("The configuration file %q could not be read.", filename),
-			})
+			}))
 			continue
 		}
 		if strings.HasSuffix(filename, ".json") {
@@ -45,18 +45,34 @@ func loadModule(fs FS, dir string) (*Module, Diagnostics) {
 			continue
 		}
 
-		contentDiags := LoadModuleFromFile(file, mod)
+		// dirFiles returns override files after all of the primary files,
+		// so that there is always a complete configuration to merge into.
+		if isOverrideFile(filename) {
+			contentDiags := loadModuleFromOverrideFile(file, mod, opts)
+			overrideDiags = append(overrideDiags, contentDiags...)
+			continue
+		}
+
+		contentDiags := loadModuleFromFile(file, mod, opts)
 		diags = append(diags, contentDiags...)
 	}
 
-	return mod, diagnosticsHCL(diags)
+	return mod, diagnosticsHCL(diags, opts), diagnosticsHCL(overrideDiags, opts)
 }
 
 // LoadModuleFromFile reads given file, interprets it and stores in given Module
 // This is useful for any caller which does tokenization/parsing on its own
 // e.g. because it will reuse these parsed files later for more detailed
 // interpretation.
+//
+// Override files must instead be loaded with LoadModuleFromOverrideFile,
+// after all of the module's other files. Neither records the source ranges
+// of the elements.
 func LoadModuleFromFile(file *hcl.File, mod *Module) hcl.Diagnostics {
+	return loadModuleFromFile(file, mod, newDecodeOptions(LoadOptions{}))
+}
+
+func loadModuleFromFile(file *hcl.File, mod *Module, opts *decodeOptions) hcl.Diagnostics {
 	var diags hcl.Diagnostics
 	content, _, contentDiags := file.Body.PartialContent(rootSchema)
 	diags = append(diags, contentDiags...)
@@ -77,10 +93,58 @@ func LoadModuleFromFile(file *hcl.File, mod *Module) hcl.Diagnostics {
 				}
 			}
 
+			if attr, defined := content.Attributes["experiments"]; defined {
+				experiments, expDiags := decodeExperiments(attr, opts)
+				diags = append(diags, expDiags...)
+				mod.Experiments = append(mod.Experiments, experiments...)
+			}
+
 			for _, innerBlock := range content.Blocks {
 				switch innerBlock.Type {
+				case "backend":
+					b, bDiags := decodeBackendBlock(innerBlock, file, opts)
+					diags = append(diags, bDiags...)
+					if prev := mod.Backend; prev != nil {
+						diags = append(diags, opts.diagnostic("duplicate-backend", &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Duplicate backend configuration",
+							Detail:   fmt.Sprintf("A module may have only one backend configuration. The backend was previously configured at %s:%d.", prev.Pos.Filename, prev.Pos.Line),
+							Subject:  &innerBlock.DefRange,
+						}))
+						continue
+					}
+					mod.Backend = b
+
+				case "cloud":
+					c, cDiags := decodeCloudBlock(innerBlock, opts)
+					diags = append(diags, cDiags...)
+					if prev := mod.Cloud; prev != nil {
+						diags = append(diags, opts.diagnostic("duplicate-cloud", &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Duplicate cloud configuration",
+							Detail:   fmt.Sprintf("A module may have only one cloud configuration. The cloud block was previously declared at %s:%d.", prev.Pos.Filename, prev.Pos.Line),
+							Subject:  &innerBlock.DefRange,
+						}))
+						continue
+					}
+					mod.Cloud = c
+
+				case "provider_meta":
+					pm, pmDiags := decodeProviderMetaBlock(innerBlock, file, opts)
+					diags = append(diags, pmDiags...)
+					if prev, exists := mod.ProviderMeta[pm.Provider]; exists {
+						diags = append(diags, opts.diagnostic("duplicate-provider-meta", &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Duplicate provider_meta block",
+							Detail:   fmt.Sprintf("A provider_meta block for provider %q was already declared at %s:%d.", pm.Provider, prev.Pos.Filename, prev.Pos.Line),
+							Subject:  &innerBlock.DefRange,
+						}))
+						continue
+					}
+					mod.ProviderMeta[pm.Provider] = pm
+
 				case "required_providers":
-					reqs, reqsDiags := decodeRequiredProvidersBlock(innerBlock)
+					reqs, reqsDiags := decodeRequiredProvidersBlock(innerBlock, opts)
 					diags = append(diags, reqsDiags...)
 					for name, req := range reqs {
 						if _, exists := mod.RequiredProviders[name]; !exists {
@@ -89,12 +153,12 @@ func LoadModuleFromFile(file *hcl.File, mod *Module) hcl.Diagnostics {
 							if req.Source != "" {
 								source := mod.RequiredProviders[name].Source
 								if source != "" && source != req.Source {
-									diags = append(diags, &hcl.Diagnostic{
+									diags = append(diags, opts.diagnostic("multiple-provider-sources", &hcl.Diagnostic{
 										Severity: hcl.DiagError,
 										Summary:  "Multiple provider source attributes",
 										Detail:   fmt.Sprintf("Found multiple source attributes for provider %s: %q, %q", name, source, req.Source),
 										Subject:  &innerBlock.DefRange,
-									})
+									}))
 								} else {
 									mod.RequiredProviders[name].Source = req.Source
 								}
@@ -107,273 +171,87 @@ func LoadModuleFromFile(file *hcl.File, mod *Module) hcl.Diagnostics {
 			}
 
 		case "variable":
-			content, _, contentDiags := block.Body.PartialContent(variableSchema)
-			diags = append(diags, contentDiags...)
-
-			name := block.Labels[0]
-			v := &Variable{
-				Name: name,
-				Pos:  sourcePosHCL(block.DefRange),
-			}
-
-			mod.Variables[name] = v
-
-			if attr, defined := content.Attributes["type"]; defined {
-				// We handle this particular attribute in a somewhat-tricky way:
-				// since Terraform may evolve its type expression syntax in
-				// future versions, we don't want to be overly-strict in how
-				// we handle it here, and so we'll instead just take the raw
-				// source provided by the user, using the source location
-				// information in the expression object.
-				//
-				// However, older versions of Terraform expected the type
-				// to be a string containing a keyword, so we'll need to
-				// handle that as a special case first for backward compatibility.
-
-				var typeExpr string
-
-				var typeExprAsStr string
-				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &typeExprAsStr)
-				if !valDiags.HasErrors() {
-					typeExpr = typeExprAsStr
-				} else {
-					rng := attr.Expr.Range()
-					typeExpr = string(rng.SliceBytes(file.Bytes))
-				}
 
-				v.Type = typeExpr
-			}
-
-			if attr, defined := content.Attributes["description"]; defined {
-				var description string
-				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &description)
-				diags = append(diags, valDiags...)
-				v.Description = description
-			}
-
-			if attr, defined := content.Attributes["default"]; defined {
-				// To avoid the caller needing to deal with cty here, we'll
-				// use its JSON encoding to convert into an
-				// approximately-equivalent plain Go interface{} value
-				// to return.
-				val, valDiags := attr.Expr.Value(nil)
-				diags = append(diags, valDiags...)
-				if val.IsWhollyKnown() { // should only be false if there are errors in the input
-					valJSON, err := ctyjson.Marshal(val, val.Type())
-					if err != nil {
-						// Should never happen, since all possible known
-						// values have a JSON mapping.
-						panic(fmt.Errorf("failed to serialize default value as JSON: %s", err))
-					}
-					var def interface{}
-					err = json.Unmarshal(valJSON, &def)
-					if err != nil {
-						// Again should never happen, because valJSON is
-						// guaranteed valid by ctyjson.Marshal.
-						panic(fmt.Errorf("failed to re-parse default value from JSON: %s", err))
-					}
-					v.Default = def
-				}
-			} else {
-				v.Required = true
-			}
-
-			if attr, defined := content.Attributes["sensitive"]; defined {
-				var sensitive bool
-				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &sensitive)
-				diags = append(diags, valDiags...)
-				v.Sensitive = sensitive
-			}
+			v, vDiags := decodeVariableBlock(block, file, opts)
+			diags = append(diags, vDiags...)
+			mod.Variables[v.Name] = v
 
 		case "output":
 
-			content, _, contentDiags := block.Body.PartialContent(outputSchema)
-			diags = append(diags, contentDiags...)
-
-			name := block.Labels[0]
-			o := &Output{
-				Name: name,
-				Pos:  sourcePosHCL(block.DefRange),
-			}
+			o, oDiags := decodeOutputBlock(block, file, opts)
+			diags = append(diags, oDiags...)
+			mod.Outputs[o.Name] = o
 
-			mod.Outputs[name] = o
+		case "locals":
 
-			if attr, defined := content.Attributes["description"]; defined {
-				var description string
-				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &description)
-				diags = append(diags, valDiags...)
-				o.Description = description
-			}
+			attrs, attrDiags := block.Body.JustAttributes()
+			diags = append(diags, attrDiags...)
 
-			if attr, defined := content.Attributes["sensitive"]; defined {
-				var sensitive bool
-				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &sensitive)
-				diags = append(diags, valDiags...)
-				o.Sensitive = sensitive
+			for name, attr := range attrs {
+				mod.Locals[name] = &Local{
+					Name:       name,
+					Expression: newExpression(attr.Expr, file, opts),
+					Pos:        sourcePosHCL(attr.Range, opts),
+				}
 			}
 
 		case "provider":
 
-			content, _, contentDiags := block.Body.PartialContent(providerConfigSchema)
-			diags = append(diags, contentDiags...)
+			pc, pcDiags := decodeProviderConfigBlock(block, file, opts)
+			diags = append(diags, pcDiags...)
 
-			name := block.Labels[0]
 			// Even if there isn't an explicit version required, we still
 			// need an entry in our map to signal the unversioned dependency.
-			if _, exists := mod.RequiredProviders[name]; !exists {
-				mod.RequiredProviders[name] = &ProviderRequirement{}
+			if _, exists := mod.RequiredProviders[pc.Name]; !exists {
+				mod.RequiredProviders[pc.Name] = &ProviderRequirement{}
 			}
-			if attr, defined := content.Attributes["version"]; defined {
-				var version string
-				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &version)
-				diags = append(diags, valDiags...)
-				if !valDiags.HasErrors() {
-					mod.RequiredProviders[name].VersionConstraints = append(mod.RequiredProviders[name].VersionConstraints, version)
-				}
+			if pc.Version != "" {
+				mod.RequiredProviders[pc.Name].VersionConstraints = append(mod.RequiredProviders[pc.Name].VersionConstraints, pc.Version)
 			}
 
-			providerKey := name
-			var alias string
-			if attr, defined := content.Attributes["alias"]; defined {
-				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &alias)
-				diags = append(diags, valDiags...)
-				if !valDiags.HasErrors() && alias != "" {
-					providerKey = fmt.Sprintf("%s.%s", name, alias)
-				}
-			}
-
-			mod.ProviderConfigs[providerKey] = &ProviderConfig{
-				Name:  name,
-				Alias: alias,
-			}
+			mod.ProviderConfigs[pc.MapKey()] = pc
 
 		case "resource", "data":
 
-			content, remaining, contentDiags := block.Body.PartialContent(resourceSchema)
-			diags = append(diags, contentDiags...)
-
-			typeName := block.Labels[0]
-			name := block.Labels[1]
-
-			r := &Resource{
-				Type: typeName,
-				Name: name,
-				Pos:  sourcePosHCL(block.DefRange),
-			}
-
-			var resourcesMap map[string]*Resource
-
-			switch block.Type {
-			case "resource":
-				r.Mode = ManagedResourceMode
-				resourcesMap = mod.ManagedResources
-				attrs, attrDiags := NewAttributesFromBody(remaining, file)
-				diags = append(diags, attrDiags...)
-				if attrDiags.HasErrors() {
-					return diags
-				}
-				r.Attributes = attrs
-
-			case "data":
-				r.Mode = DataResourceMode
-				resourcesMap = mod.DataResources
-			}
-
-			key := r.MapKey()
-
-			resourcesMap[key] = r
-
-			if attr, defined := content.Attributes["provider"]; defined {
-				// New style here is to provide this as a naked traversal
-				// expression, but we also support quoted references for
-				// older configurations that predated this convention.
-				traversal, travDiags := hcl.AbsTraversalForExpr(attr.Expr)
-				if travDiags.HasErrors() {
-					traversal = nil // in case we got any partial results
-
-					// Fall back on trying to parse as a string
-					var travStr string
-					valDiags := gohcl.DecodeExpression(attr.Expr, nil, &travStr)
-					if !valDiags.HasErrors() {
-						var strDiags hcl.Diagnostics
-						traversal, strDiags = hclsyntax.ParseTraversalAbs([]byte(travStr), "", hcl.Pos{})
-						if strDiags.HasErrors() {
-							traversal = nil
-						}
-					}
-				}
+			r, rDiags := decodeResourceBlock(block, file, opts)
+			diags = append(diags, rDiags...)
 
-				// If we get out here with a nil traversal then we didn't
-				// succeed in processing the input.
-				if len(traversal) > 0 {
-					providerName := traversal.RootName()
-					alias := ""
-					if len(traversal) > 1 {
-						if getAttr, ok := traversal[1].(hcl.TraverseAttr); ok {
-							alias = getAttr.Name
-						}
-					}
-					r.Provider = ProviderRef{
-						Name:  providerName,
-						Alias: alias,
-					}
-				} else {
-					diags = append(diags, &hcl.Diagnostic{
-						Severity: hcl.DiagError,
-						Summary:  "Invalid provider reference",
-						Detail:   "Provider argument requires a provider name followed by an optional alias, like \"aws.foo\".",
-						Subject:  attr.Expr.Range().Ptr(),
-					})
-				}
-			} else {
-				// If provider _isn't_ set then we'll infer it from the
-				// resource type.
-				r.Provider = ProviderRef{
-					Name: resourceTypeDefaultProviderName(r.Type),
-				}
+			switch r.Mode {
+			case ManagedResourceMode:
+				mod.ManagedResources[r.MapKey()] = r
+			case DataResourceMode:
+				mod.DataResources[r.MapKey()] = r
 			}
 
 		case "module":
 
-			content, remaining, contentDiags := block.Body.PartialContent(moduleCallSchema)
-			diags = append(diags, contentDiags...)
+			mc, mcDiags := decodeModuleCallBlock(block, file, opts)
+			diags = append(diags, mcDiags...)
+			mod.ModuleCalls[mc.Name] = mc
 
-			name := block.Labels[0]
-			mc := &ModuleCall{
-				Name: block.Labels[0],
-				Pos:  sourcePosHCL(block.DefRange),
-			}
+		case "check":
 
-			// check if this is overriding an existing module
-			var origSource string
-			if origMod, exists := mod.ModuleCalls[name]; exists {
-				origSource = origMod.Source
-			}
+			c, cDiags := decodeCheckBlock(block, file, opts)
+			diags = append(diags, cDiags...)
+			mod.Checks[c.Name] = c
 
-			mod.ModuleCalls[name] = mc
+		case "moved":
 
-			attrs, attrDiags := remaining.JustAttributes()
-			diags = append(diags, attrDiags...)
-			mc.Attributes, attrDiags = NewAttributes(attrs, file)
-			diags = append(diags, attrDiags...)
+			mv, mvDiags := decodeMovedBlock(block, file, opts)
+			diags = append(diags, mvDiags...)
+			mod.Moved = append(mod.Moved, mv)
 
-			if attr, defined := content.Attributes["source"]; defined {
-				var source string
-				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &source)
-				diags = append(diags, valDiags...)
-				mc.Source = source
-			}
+		case "removed":
 
-			if mc.Source == "" {
-				mc.Source = origSource
-			}
+			r, rDiags := decodeRemovedBlock(block, file, opts)
+			diags = append(diags, rDiags...)
+			mod.Removed = append(mod.Removed, r)
 
-			if attr, defined := content.Attributes["version"]; defined {
-				var version string
-				valDiags := gohcl.DecodeExpression(attr.Expr, nil, &version)
-				diags = append(diags, valDiags...)
-				mc.Version = version
-			}
+		case "import":
+
+			imp, impDiags := decodeImportBlock(block, file, opts)
+			diags = append(diags, impDiags...)
+			mod.Imports = append(mod.Imports, imp)
 
 		default:
 			// Should never happen because our cases above should be
@@ -8,9 +8,10 @@ import (
 
 	legacyhcl "github.com/hashicorp/hcl"
 	legacyast "github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/v2"
 )
 
-func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
+func loadModuleLegacyHCL(fs FS, dir string, opts *decodeOptions) (*Module, Diagnostics) {
 	// This implementation is intentionally more quick-and-dirty than the
 	// main loader. In particular, it doesn't bother to keep careful track
 	// of multiple error messages because we always fall back on returning
@@ -18,25 +19,32 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 	// an error, and thus the errors here are not seen by the end-caller.
 	mod := NewModule(dir)
 
-	primaryPaths, diags := dirFiles(fs, dir)
+	primaryPaths, diags := dirFiles(fs, dir, opts)
 	if diags.HasErrors() {
-		return mod, diagnosticsHCL(diags)
+		return mod, diagnosticsHCL(diags, opts)
 	}
 
+	// providerVersions records the version constraint of the last provider
+	// block for each provider in the primary files, so that an override file
+	// can replace it.
+	providerVersions := make(map[string]string)
+
 	for _, filename := range primaryPaths {
+		override := isOverrideFile(filename)
+
 		src, err := fs.ReadFile(filename)
 		if err != nil {
-			return mod, diagnosticsErrorf("Error reading %s: %s", filename, err)
+			return mod, diagnosticsErrorf("read-file-failed", "Error reading %s: %s", filename, err)
 		}
 
 		hclRoot, err := legacyhcl.Parse(string(src))
 		if err != nil {
-			return mod, diagnosticsErrorf("Error parsing %s: %s", filename, err)
+			return mod, diagnosticsErrorf("invalid-configuration", "Error parsing %s: %s", filename, err)
 		}
 
 		list, ok := hclRoot.Node.(*legacyast.ObjectList)
 		if !ok {
-			return mod, diagnosticsErrorf("Error parsing %s: no root object", filename)
+			return mod, diagnosticsErrorf("invalid-configuration", "Error parsing %s: no root object", filename)
 		}
 
 		for _, item := range list.Filter("terraform").Items {
@@ -58,12 +66,12 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 			var block TerraformBlock
 			err = legacyhcl.DecodeObject(&block, item.Val)
 			if err != nil {
-				return nil, diagnosticsErrorf("terraform block: %s", err)
+				return nil, diagnosticsErrorf("invalid-configuration", "terraform block: %s", err)
 			}
 
 			for _, field := range block.Fields {
 				if field == "RequiredProviders" {
-					return nil, diagnosticsErrorf("terraform.required_providers must not exist")
+					return nil, diagnosticsErrorf("invalid-configuration", "terraform.required_providers must not exist")
 				}
 			}
 
@@ -85,7 +93,7 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 				unwrapLegacyHCLObjectKeysFromJSON(item, 1)
 
 				if len(item.Keys) != 1 {
-					return nil, diagnosticsErrorf("variable block at %s has no label", item.Pos())
+					return nil, diagnosticsErrorf("invalid-configuration", "variable block at %s has no label", item.Pos())
 				}
 
 				name := item.Keys[0].Token.Value().(string)
@@ -93,7 +101,7 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 				var block VariableBlock
 				err := legacyhcl.DecodeObject(&block, item.Val)
 				if err != nil {
-					return nil, diagnosticsErrorf("invalid variable block at %s: %s", item.Pos(), err)
+					return nil, diagnosticsErrorf("invalid-configuration", "invalid variable block at %s: %s", item.Pos(), err)
 				}
 
 				// Clean up legacy HCL decoding ambiguity by unwrapping list of maps
@@ -107,16 +115,39 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 					block.Default = def
 				}
 
+				var tc *TypeConstraint
+				if block.Type != "" {
+					tc, err = legacyTypeConstraint(block.Type)
+					if err != nil {
+						return nil, diagnosticsErrorf("invalid-configuration", "invalid variable block at %s: %s", item.Pos(), err)
+					}
+				}
+
 				v := &Variable{
-					Name:        name,
-					Type:        block.Type,
-					Description: block.Description,
-					Default:     block.Default,
-					Required:    block.Default == nil,
-					Pos:         sourcePosLegacyHCL(item.Pos(), filename),
+					Name:           name,
+					Type:           block.Type,
+					TypeConstraint: tc,
+					Description:    block.Description,
+					Default:        block.Default,
+					Required:       block.Default == nil,
+					Pos:            sourcePosLegacyHCL(item.Pos(), filename, opts),
+				}
+				if block.Default != nil {
+					v.DefaultValue, err = goValueToCty(block.Default)
+					if err != nil {
+						return nil, diagnosticsErrorf("invalid-variable-default", "invalid default value for variable at %s: %s", item.Pos(), err)
+					}
+				}
+				if override {
+					base, exists := mod.Variables[name]
+					if !exists {
+						return nil, diagnosticsErrorf("missing-override-base", "there is no variable named %q to override", name)
+					}
+					base.merge(v, legacyOverrideContent(block.Fields))
+					continue
 				}
 				if _, exists := mod.Variables[name]; exists {
-					return nil, diagnosticsErrorf("duplicate variable block for %q", name)
+					return nil, diagnosticsErrorf("duplicate-variable", "duplicate variable block for %q", name)
 				}
 				mod.Variables[name] = v
 
@@ -128,13 +159,14 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 			type OutputBlock struct {
 				Description string
 				Sensitive   bool
+				Fields      []string `hcl:",decodedFields"`
 			}
 
 			for _, item := range outputs.Items {
 				unwrapLegacyHCLObjectKeysFromJSON(item, 1)
 
 				if len(item.Keys) != 1 {
-					return nil, diagnosticsErrorf("output block at %s has no label", item.Pos())
+					return nil, diagnosticsErrorf("invalid-configuration", "output block at %s has no label", item.Pos())
 				}
 
 				name := item.Keys[0].Token.Value().(string)
@@ -142,17 +174,25 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 				var block OutputBlock
 				err := legacyhcl.DecodeObject(&block, item.Val)
 				if err != nil {
-					return nil, diagnosticsErrorf("invalid output block at %s: %s", item.Pos(), err)
+					return nil, diagnosticsErrorf("invalid-configuration", "invalid output block at %s: %s", item.Pos(), err)
 				}
 
 				o := &Output{
 					Name:        name,
 					Description: block.Description,
 					Sensitive:   block.Sensitive,
-					Pos:         sourcePosLegacyHCL(item.Pos(), filename),
+					Pos:         sourcePosLegacyHCL(item.Pos(), filename, opts),
+				}
+				if override {
+					base, exists := mod.Outputs[name]
+					if !exists {
+						return nil, diagnosticsErrorf("missing-override-base", "there is no output named %q to override", name)
+					}
+					base.merge(o, legacyOverrideContent(block.Fields))
+					continue
 				}
 				if _, exists := mod.Outputs[name]; exists {
-					return nil, diagnosticsErrorf("duplicate output block for %q", name)
+					return nil, diagnosticsErrorf("duplicate-output", "duplicate output block for %q", name)
 				}
 				mod.Outputs[name] = o
 			}
@@ -163,13 +203,14 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 				resources = resources.Children()
 				type ResourceBlock struct {
 					Provider string
+					Fields   []string `hcl:",decodedFields"`
 				}
 
 				for _, item := range resources.Items {
 					unwrapLegacyHCLObjectKeysFromJSON(item, 2)
 
 					if len(item.Keys) != 2 {
-						return nil, diagnosticsErrorf("resource block at %s has wrong label count", item.Pos())
+						return nil, diagnosticsErrorf("invalid-configuration", "resource block at %s has wrong label count", item.Pos())
 					}
 
 					typeName := item.Keys[0].Token.Value().(string)
@@ -188,33 +229,32 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 					var block ResourceBlock
 					err := legacyhcl.DecodeObject(&block, item.Val)
 					if err != nil {
-						return nil, diagnosticsErrorf("invalid resource block at %s: %s", item.Pos(), err)
+						return nil, diagnosticsErrorf("invalid-configuration", "invalid resource block at %s: %s", item.Pos(), err)
 					}
 
-					var providerName, providerAlias string
-					if dotPos := strings.IndexByte(block.Provider, '.'); dotPos != -1 {
-						providerName = block.Provider[:dotPos]
-						providerAlias = block.Provider[dotPos+1:]
-					} else {
-						providerName = block.Provider
-					}
-					if providerName == "" {
-						providerName = resourceTypeDefaultProviderName(typeName)
+					provider := legacyProviderRef(block.Provider)
+					if provider.Name == "" {
+						provider.Name = resourceTypeDefaultProviderName(typeName)
 					}
 
 					r := &Resource{
-						Mode: mode,
-						Type: typeName,
-						Name: name,
-						Provider: ProviderRef{
-							Name:  providerName,
-							Alias: providerAlias,
-						},
-						Pos: sourcePosLegacyHCL(item.Pos(), filename),
+						Mode:     mode,
+						Type:     typeName,
+						Name:     name,
+						Provider: provider,
+						Pos:      sourcePosLegacyHCL(item.Pos(), filename, opts),
 					}
 					key := r.MapKey()
+					if override {
+						base, exists := rMap[key]
+						if !exists {
+							return nil, diagnosticsErrorf("missing-override-base", "there is no %s block for %q to override", blockType, key)
+						}
+						base.merge(r, legacyOverrideContent(block.Fields))
+						continue
+					}
 					if _, exists := rMap[key]; exists {
-						return nil, diagnosticsErrorf("duplicate resource block for %q", key)
+						return nil, diagnosticsErrorf("duplicate-resource", "duplicate resource block for %q", key)
 					}
 					rMap[key] = r
 				}
@@ -225,15 +265,17 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 		if moduleCalls := list.Filter("module"); len(moduleCalls.Items) > 0 {
 			moduleCalls = moduleCalls.Children()
 			type ModuleBlock struct {
-				Source  string
-				Version string
+				Source    string
+				Version   string
+				Providers map[string]string
+				Fields    []string `hcl:",decodedFields"`
 			}
 
 			for _, item := range moduleCalls.Items {
 				unwrapLegacyHCLObjectKeysFromJSON(item, 1)
 
 				if len(item.Keys) != 1 {
-					return nil, diagnosticsErrorf("module block at %s has no label", item.Pos())
+					return nil, diagnosticsErrorf("invalid-configuration", "module block at %s has no label", item.Pos())
 				}
 
 				name := item.Keys[0].Token.Value().(string)
@@ -241,20 +283,28 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 				var block ModuleBlock
 				err := legacyhcl.DecodeObject(&block, item.Val)
 				if err != nil {
-					return nil, diagnosticsErrorf("module block at %s: %s", item.Pos(), err)
+					return nil, diagnosticsErrorf("invalid-configuration", "module block at %s: %s", item.Pos(), err)
 				}
 
 				mc := &ModuleCall{
 					Name:    name,
 					Source:  block.Source,
 					Version: block.Version,
-					Pos:     sourcePosLegacyHCL(item.Pos(), filename),
+					Pos:     sourcePosLegacyHCL(item.Pos(), filename, opts),
+				}
+				if len(block.Providers) > 0 {
+					mc.Providers = make(ModuleCallProviders, len(block.Providers))
+					for child, parent := range block.Providers {
+						mc.Providers[legacyProviderRef(child)] = legacyProviderRef(parent)
+					}
 				}
-				// it's possible this module call is from an override file
-				if origMod, exists := mod.ModuleCalls[name]; exists {
-					if mc.Source == "" {
-						mc.Source = origMod.Source
+				if override {
+					base, exists := mod.ModuleCalls[name]
+					if !exists {
+						return nil, diagnosticsErrorf("missing-override-base", "there is no module call named %q to override", name)
 					}
+					base.merge(mc, legacyOverrideContent(block.Fields))
+					continue
 				}
 				mod.ModuleCalls[name] = mc
 			}
@@ -270,7 +320,7 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 				unwrapLegacyHCLObjectKeysFromJSON(item, 1)
 
 				if len(item.Keys) != 1 {
-					return nil, diagnosticsErrorf("provider block at %s has no label", item.Pos())
+					return nil, diagnosticsErrorf("invalid-configuration", "provider block at %s has no label", item.Pos())
 				}
 
 				name := item.Keys[0].Token.Value().(string)
@@ -278,7 +328,7 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 				var block ProviderBlock
 				err := legacyhcl.DecodeObject(&block, item.Val)
 				if err != nil {
-					return nil, diagnosticsErrorf("invalid provider block at %s: %s", item.Pos(), err)
+					return nil, diagnosticsErrorf("invalid-configuration", "invalid provider block at %s: %s", item.Pos(), err)
 				}
 				// Even if there wasn't an explicit version required, we still
 				// need an entry in our map to signal the unversioned dependency.
@@ -286,9 +336,22 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 					mod.RequiredProviders[name] = &ProviderRequirement{}
 				}
 
-				if block.Version != "" {
-					mod.RequiredProviders[name].VersionConstraints = append(mod.RequiredProviders[name].VersionConstraints, block.Version)
+				if block.Version == "" {
+					continue
 				}
+				req := mod.RequiredProviders[name]
+				if override {
+					// The override's version constraint replaces the one
+					// from the overridden block.
+					for i, constraint := range req.VersionConstraints {
+						if constraint == providerVersions[name] {
+							req.VersionConstraints = append(req.VersionConstraints[:i:i], req.VersionConstraints[i+1:]...)
+							break
+						}
+					}
+				}
+				req.VersionConstraints = append(req.VersionConstraints, block.Version)
+				providerVersions[name] = block.Version
 			}
 		}
 	}
@@ -296,6 +359,34 @@ func loadModuleLegacyHCL(fs FS, dir string) (*Module, Diagnostics) {
 	return mod, nil
 }
 
+// legacyOverrideContent returns body content that records the given decoded
+// fields of a block from a legacy override file as set arguments, so that
+// the block can be merged in the same way as one from the main loader.
+func legacyOverrideContent(fields []string) *hcl.BodyContent {
+	content := &hcl.BodyContent{
+		Attributes: make(hcl.Attributes, len(fields)),
+	}
+	for _, field := range fields {
+		name := strings.ToLower(field)
+		content.Attributes[name] = &hcl.Attribute{Name: name}
+	}
+	return content
+}
+
+// legacyProviderRef parses a provider configuration reference given as a
+// string, like "aws.west", as was required in older configurations.
+func legacyProviderRef(raw string) ProviderRef {
+	if dotPos := strings.IndexByte(raw, '.'); dotPos != -1 {
+		return ProviderRef{
+			Name:  raw[:dotPos],
+			Alias: raw[dotPos+1:],
+		}
+	}
+	return ProviderRef{
+		Name: raw,
+	}
+}
+
 // unwrapLegacyHCLObjectKeysFromJSON cleans up an edge case that can occur when
 // parsing JSON as input: if we're parsing JSON then directly nested
 // items will show up as additional "keys".
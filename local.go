@@ -0,0 +1,14 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+// Local represents a single local value from a "locals" block within a
+// module.
+type Local struct {
+	Name string `json:"name"`
+
+	*Expression
+
+	Pos SourcePos `json:"pos"`
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleManifestRecord describes a single module installed by
+// "terraform init", as recorded in the module manifest.
+type ModuleManifestRecord struct {
+	// Key is the module path of the installed module without the "module."
+	// prefixes, like "network.subnets".
+	Key string `json:"Key"`
+
+	Source  string `json:"Source"`
+	Version string `json:"Version,omitempty"`
+
+	// Dir is the directory the module was installed into, relative to the
+	// root module directory.
+	Dir string `json:"Dir"`
+}
+
+// ModuleManifest is the manifest that "terraform init" writes to
+// .terraform/modules/modules.json describing the modules it installed,
+// keyed by ModuleManifestRecord.Key.
+type ModuleManifest map[string]*ModuleManifestRecord
+
+// moduleManifestPath is the location of the module manifest relative to the
+// root module directory.
+var moduleManifestPath = filepath.Join(".terraform", "modules", "modules.json")
+
+// LoadModuleManifest reads the module manifest written by "terraform init"
+// for the root module in the given directory of the given FS.
+//
+// If no modules have been installed for the root module then the result is
+// nil, without any diagnostics.
+func LoadModuleManifest(fs FS, rootDir string) (ModuleManifest, Diagnostics) {
+	filename := filepath.Join(rootDir, moduleManifestPath)
+	src, err := fs.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, diagnosticsErrorf("read-manifest-failed", "Error reading %s: %s", filename, err)
+	}
+
+	var raw struct {
+		Records []*ModuleManifestRecord `json:"Modules"`
+	}
+	if err := json.Unmarshal(src, &raw); err != nil {
+		return nil, diagnosticsErrorf("invalid-manifest", "Error parsing %s: %s", filename, err)
+	}
+
+	manifest := make(ModuleManifest, len(raw.Records))
+	for _, record := range raw.Records {
+		manifest[record.Key] = record
+	}
+	return manifest, nil
+}
+
+// Record returns the record for the module with the given module path, like
+// "module.network.module.subnets", or nil if there is no such record.
+func (m ModuleManifest) Record(modulePath string) *ModuleManifestRecord {
+	return m[manifestKey(modulePath)]
+}
+
+func manifestKey(modulePath string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(modulePath, "module."), ".module.", ".")
+}
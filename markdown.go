@@ -19,16 +19,37 @@ func RenderMarkdown(w io.Writer, module *Module) error {
 		"commas": func(s []string) string {
 			return strings.Join(s, ", ")
 		},
+		"pairs": func(m map[string]string) []string {
+			var ret []string
+			for _, k := range sortedKeys(m) {
+				ret = append(ret, k+"="+m[k])
+			}
+			return ret
+		},
 		"json": func(v interface{}) (string, error) {
 			j, err := json.Marshal(v)
 			return string(j), err
 		},
+		"refs": func(refs []Reference) string {
+			var subjects []string
+			seen := make(map[string]bool)
+			for _, ref := range refs {
+				if seen[ref.Subject] {
+					continue
+				}
+				seen[ref.Subject] = true
+				subjects = append(subjects, "`"+ref.Subject+"`")
+			}
+			return strings.Join(subjects, ", ")
+		},
 		"severity": func(s DiagSeverity) string {
 			switch s {
 			case DiagError:
 				return "Error: "
 			case DiagWarning:
 				return "Warning: "
+			case DiagHint:
+				return "Hint: "
 			default:
 				return ""
 			}
@@ -55,12 +76,32 @@ Provider Requirements:
 * **{{ $name }}{{ if $req.Source }} ({{ $req.Source | tt }}){{ end }}:** {{ if $req.VersionConstraints }}{{ commas $req.VersionConstraints | tt }}{{ else }}(any version){{ end }}
 {{- end}}{{end}}
 
+{{- with .Backend}}
+
+Backend: {{ tt .Type }}{{end}}
+
+{{- with .Cloud}}
+
+Cloud:{{ if .Organization }} organization {{ tt .Organization }}{{ end }}{{ if .Hostname }} on {{ tt .Hostname }}{{ end }}
+{{- with .Workspaces }}{{ if .Name }}, workspace {{ tt .Name }}{{ end }}{{ if .Tags }}, workspaces tagged {{ commas .Tags | tt }}{{ end }}{{ if .KeyValueTags }}, workspaces tagged {{ pairs .KeyValueTags | commas | tt }}{{ end }}{{ if .Project }} in project {{ tt .Project }}{{ end }}{{ end }}{{end}}
+
 {{- if .Variables}}
 
 ## Input Variables
 {{- range .Variables }}
 * {{ tt .Name }}{{ if .Required }} (required){{else}} (default {{ json .Default | tt }}){{end}}
+{{- if not .IsNullable }} (non-nullable){{ end }}{{ if .Ephemeral }} (ephemeral){{ end }}
 {{- if .Description}}: {{ .Description }}{{ end }}
+{{- range .Validations }}
+  * Validation: {{ .ErrorMessage }}{{ if .Condition }} ({{ tt .Condition.Source }}){{ end }}
+{{- end}}
+{{- end}}{{end}}
+
+{{- if .Locals}}
+
+## Local Values
+{{- range .Locals }}
+* {{ tt .Name }}{{ if .References }} (derived from {{ refs .References }}){{ end }}
 {{- end}}{{end}}
 
 {{- if .Outputs}}
@@ -91,6 +132,16 @@ Provider Requirements:
 * {{ tt .Name }} from {{ tt .Source }}{{ if .Version }} ({{ tt .Version }}){{ end }}
 {{- end}}{{end}}
 
+{{- if .Checks}}
+
+## Checks
+{{- range .Checks }}
+* {{ tt .Name }}{{ with .DataResource }} using {{ printf "data.%s.%s" .Type .Name | tt }} from {{ tt .Provider.Name }}{{ end }}
+{{- range .Asserts }}
+  * Assert: {{ .ErrorMessage }}{{ if .Condition }} ({{ tt .Condition.Source }}){{ end }}
+{{- end}}
+{{- end}}{{end}}
+
 {{- if .Diagnostics}}
 
 ## Problems
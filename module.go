@@ -3,6 +3,11 @@
 
 package terraparse
 
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
 // Module is the top-level type representing a parsed and processed Terraform
 // module.
 type Module struct {
@@ -11,14 +16,29 @@ type Module struct {
 
 	Variables map[string]*Variable `json:"variables"`
 	Outputs   map[string]*Output   `json:"outputs"`
+	Locals    map[string]*Local    `json:"locals,omitempty"`
 
 	RequiredCore      []string                        `json:"required_core,omitempty"`
 	RequiredProviders map[string]*ProviderRequirement `json:"required_providers"`
 
+	// Backend and Cloud record where a root module's state is stored, if
+	// configured. At most one of them is set in a valid module.
+	Backend      *Backend                 `json:"backend,omitempty"`
+	Cloud        *Cloud                   `json:"cloud,omitempty"`
+	Experiments  []string                 `json:"experiments,omitempty"`
+	ProviderMeta map[string]*ProviderMeta `json:"provider_meta,omitempty"`
+
 	ProviderConfigs  map[string]*ProviderConfig `json:"provider_configs,omitempty"`
 	ManagedResources map[string]*Resource       `json:"managed_resources"`
 	DataResources    map[string]*Resource       `json:"data_resources"`
 	ModuleCalls      map[string]*ModuleCall     `json:"module_calls"`
+	Checks           map[string]*Check          `json:"checks,omitempty"`
+
+	// Moved, Removed and Imports are the module's refactoring blocks, in
+	// the order they appear in the configuration.
+	Moved   []*Moved   `json:"moved,omitempty"`
+	Removed []*Removed `json:"removed,omitempty"`
+	Imports []*Import  `json:"imports,omitempty"`
 
 	// Diagnostics records any errors and warnings that were detected during
 	// loading, primarily for inclusion in serialized forms of the module
@@ -30,6 +50,58 @@ type Module struct {
 type ProviderConfig struct {
 	Name  string `json:"name"`
 	Alias string `json:"alias,omitempty"`
+
+	// Version is the version constraint from the legacy "version" argument,
+	// if any. It is also included in the module's RequiredProviders.
+	Version string `json:"version,omitempty"`
+
+	// Attributes and Blocks are the arguments and nested blocks in the
+	// provider block, other than the "alias" and "version" meta-arguments.
+	Attributes Attributes `json:"attributes,omitempty"`
+	Blocks     []*Block   `json:"blocks,omitempty"`
+
+	Provenance
+}
+
+// MapKey returns a string that can be used to uniquely identify the receiver
+// in a map[string]*ProviderConfig.
+func (pc *ProviderConfig) MapKey() string {
+	return ProviderRef{Name: pc.Name, Alias: pc.Alias}.String()
+}
+
+// decodeProviderConfigBlock decodes a "provider" block from the given file.
+func decodeProviderConfigBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*ProviderConfig, hcl.Diagnostics) {
+	content, remaining, diags := block.Body.PartialContent(providerConfigSchema)
+
+	pc := &ProviderConfig{
+		Name: block.Labels[0],
+	}
+
+	if attr, defined := content.Attributes["version"]; defined {
+		var version string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &version)
+		diags = append(diags, valDiags...)
+		if !valDiags.HasErrors() {
+			pc.Version = version
+		}
+	}
+
+	if attr, defined := content.Attributes["alias"]; defined {
+		var alias string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &alias)
+		diags = append(diags, valDiags...)
+		if !valDiags.HasErrors() {
+			pc.Alias = alias
+		}
+	}
+
+	attrs, blocks, bodyDiags := decodeBody(remaining, file, nil, opts)
+	diags = append(diags, bodyDiags...)
+	pc.Attributes = attrs
+	pc.Blocks = blocks
+	pc.Provenance = newProvenance(block.DefRange, content.Attributes, attrs, opts)
+
+	return pc, diags
 }
 
 // NewModule creates new Module representing Terraform module at the given path
@@ -38,10 +110,13 @@ func NewModule(path string) *Module {
 		Path:              path,
 		Variables:         make(map[string]*Variable),
 		Outputs:           make(map[string]*Output),
+		Locals:            make(map[string]*Local),
 		RequiredProviders: make(map[string]*ProviderRequirement),
+		ProviderMeta:      make(map[string]*ProviderMeta),
 		ProviderConfigs:   make(map[string]*ProviderConfig),
 		ManagedResources:  make(map[string]*Resource),
 		DataResources:     make(map[string]*Resource),
 		ModuleCalls:       make(map[string]*ModuleCall),
+		Checks:            make(map[string]*Check),
 	}
 }
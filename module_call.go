@@ -3,6 +3,17 @@
 
 package terraparse
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
 // ModuleCall represents a "module" block within a module. That is, a
 // declaration of a child module from inside its parent.
 type ModuleCall struct {
@@ -11,5 +22,157 @@ type ModuleCall struct {
 	Version    string     `json:"version,omitempty"`
 	Attributes Attributes `json:"attributes,omitempty"`
 
+	// Providers maps provider configurations in the child module to the
+	// provider configurations in the calling module that are passed to them,
+	// as given in the "providers" argument.
+	Providers ModuleCallProviders `json:"providers,omitempty"`
+
+	// SourceAddr is the parsed form of Source, or nil if Source is empty
+	// or invalid.
+	SourceAddr ModuleSource `json:"source_addr,omitempty"`
+
+	// VersionConstraints is the parsed form of Version, or nil if Version
+	// is empty or invalid.
+	VersionConstraints VersionConstraints `json:"version_constraints,omitempty"`
+
+	// Count, ForEach and DependsOn are the meta-arguments of the same names,
+	// if set. Module calls don't support lifecycle blocks.
+	Count     *Expression `json:"count,omitempty"`
+	ForEach   *Expression `json:"for_each,omitempty"`
+	DependsOn []Reference `json:"depends_on,omitempty"`
+
 	Pos SourcePos `json:"pos"`
+	Provenance
+}
+
+// decodeAddrs populates the receiver's SourceAddr and VersionConstraints
+// from its Source and Version.
+func (mc *ModuleCall) decodeAddrs() Diagnostics {
+	var diags Diagnostics
+
+	if mc.Source != "" {
+		addr, err := ParseModuleSource(mc.Source)
+		if err != nil {
+			pos := mc.attributePos("source", mc.Pos)
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "invalid-module-source",
+				Summary:  "Invalid module source address",
+				Detail:   fmt.Sprintf("Failed to parse the source address for module call %q: %s.", mc.Name, err),
+				Pos:      &pos,
+			})
+		}
+		mc.SourceAddr = addr
+	}
+
+	if mc.Version != "" {
+		pos := mc.attributePos("version", mc.Pos)
+		if _, isRegistry := mc.SourceAddr.(ModuleSourceRegistry); mc.SourceAddr != nil && !isRegistry {
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "invalid-version-constraint",
+				Summary:  "Invalid version constraint",
+				Detail:   fmt.Sprintf("Module call %q has a version constraint, but version constraints are only supported for registry module sources.", mc.Name),
+				Pos:      &pos,
+			})
+		}
+
+		constraints, err := ParseVersionConstraints(mc.Version)
+		if err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "invalid-version-constraint",
+				Summary:  "Invalid version constraint",
+				Detail:   fmt.Sprintf("Failed to parse the version constraint for module call %q: %s.", mc.Name, err),
+				Pos:      &pos,
+			})
+		}
+		mc.VersionConstraints = constraints
+	}
+
+	return diags
+}
+
+// ModuleCallProviders is a map from provider configurations in a child
+// module to provider configurations in the calling module.
+type ModuleCallProviders map[ProviderRef]ProviderRef
+
+// MarshalJSON implements encoding/json.Marshaler, using the string form of
+// each child-side provider configuration as the object property name.
+func (ps ModuleCallProviders) MarshalJSON() ([]byte, error) {
+	keys := make([]string, 0, len(ps))
+	byKey := make(map[string]ProviderRef, len(ps))
+	for child, parent := range ps {
+		keys = append(keys, child.String())
+		byKey[child.String()] = parent
+	}
+	sort.Strings(keys)
+
+	out := &bytes.Buffer{}
+	out.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(strconv.Quote(k) + ":")
+		valData, err := json.Marshal(byKey[k])
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling provider %q: %w", k, err)
+		}
+		out.Write(valData)
+	}
+	out.WriteString("}")
+	return out.Bytes(), nil
+}
+
+// decodeModuleCallBlock decodes a "module" block from the given file.
+func decodeModuleCallBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*ModuleCall, hcl.Diagnostics) {
+	content, remaining, diags := block.Body.PartialContent(moduleCallSchema)
+
+	mc := &ModuleCall{
+		Name: block.Labels[0],
+		Pos:  sourcePosHCL(block.DefRange, opts),
+	}
+
+	attrs, attrDiags := remaining.JustAttributes()
+	diags = append(diags, attrDiags...)
+	mc.Attributes, attrDiags = newAttributes(attrs, file, nil, opts)
+	diags = append(diags, attrDiags...)
+	mc.Provenance = newProvenance(block.DefRange, content.Attributes, mc.Attributes, opts)
+
+	if attr, defined := content.Attributes["count"]; defined {
+		mc.Count = newExpression(attr.Expr, file, opts)
+	}
+
+	if attr, defined := content.Attributes["for_each"]; defined {
+		mc.ForEach = newExpression(attr.Expr, file, opts)
+	}
+
+	if attr, defined := content.Attributes["depends_on"]; defined {
+		refs, refsDiags := decodeDependsOn(attr, opts)
+		diags = append(diags, refsDiags...)
+		mc.DependsOn = refs
+	}
+
+	if attr, defined := content.Attributes["source"]; defined {
+		var source string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &source)
+		diags = append(diags, valDiags...)
+		mc.Source = source
+	}
+
+	if attr, defined := content.Attributes["version"]; defined {
+		var version string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &version)
+		diags = append(diags, valDiags...)
+		mc.Version = version
+	}
+
+	if attr, defined := content.Attributes["providers"]; defined {
+		providers, providersDiags := decodeModuleCallProviders(attr.Expr, opts)
+		diags = append(diags, providersDiags...)
+		mc.Providers = providers
+	}
+
+	return mc, diags
 }
@@ -0,0 +1,105 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+)
+
+// ValidateModuleCallArguments checks the arguments in each module call in
+// the tree against the input variables declared by the child module,
+// returning diagnostics describing any problems.
+//
+// In particular, it reports arguments that don't correspond to any variable
+// in the child module, required variables that have no argument, and
+// constant argument values that don't conform to their variable's type
+// constraint. Arguments whose values depend on other objects can't be
+// checked without evaluating the configuration, so only their presence is
+// considered.
+//
+// Each diagnostic refers to the position of the offending module call.
+func (t *ModuleTree) ValidateModuleCallArguments() Diagnostics {
+	var diags Diagnostics
+	for _, path := range sortedKeys(t.Modules) {
+		node := t.Modules[path]
+		if node.Parent == nil {
+			continue
+		}
+		diags = append(diags, validateModuleCallArguments(node.Call, node.Module)...)
+	}
+	return diags
+}
+
+func validateModuleCallArguments(call *ModuleCall, child *Module) Diagnostics {
+	var diags Diagnostics
+	pos := call.Pos
+
+	for _, name := range sortedKeys(call.Attributes) {
+		v, declared := child.Variables[name]
+		if !declared {
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "unsupported-module-argument",
+				Summary:  "Unsupported argument",
+				Detail:   fmt.Sprintf("Module call %q sets the argument %q, but the child module does not declare a variable with that name.", call.Name, name),
+				Pos:      &pos,
+			})
+			continue
+		}
+
+		attr := call.Attributes[name]
+		if attr.Attribute == nil || len(attr.Expr.Variables()) != 0 {
+			continue
+		}
+		val, valDiags := attr.Expr.Value(nil)
+		if valDiags.HasErrors() || !val.IsWhollyKnown() {
+			// Most likely a function call, which we can't evaluate.
+			continue
+		}
+
+		if val.IsNull() {
+			if !v.IsNullable() && v.DefaultValue.IsNull() {
+				diags = append(diags, Diagnostic{
+					Severity: DiagError,
+					Code:     "invalid-module-argument",
+					Summary:  "Invalid value for module argument",
+					Detail:   fmt.Sprintf("Module call %q sets the argument %q to null, but the child module's variable is not nullable and has no default value.", call.Name, name),
+					Pos:      &pos,
+				})
+			}
+			continue
+		}
+
+		if v.TypeConstraint == nil {
+			continue
+		}
+		if _, err := v.TypeConstraint.Convert(val); err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "invalid-module-argument",
+				Summary:  "Invalid value for module argument",
+				Detail:   fmt.Sprintf("The value of argument %q in module call %q is not suitable for the child module's variable, which has the type constraint %s: %s.", name, call.Name, v.TypeConstraint.String(), formatTypeConversionError(err)),
+				Pos:      &pos,
+			})
+		}
+	}
+
+	for _, name := range sortedKeys(child.Variables) {
+		if !child.Variables[name].Required {
+			continue
+		}
+		if _, given := call.Attributes[name]; given {
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			Severity: DiagError,
+			Code:     "missing-module-argument",
+			Summary:  "Missing required argument",
+			Detail:   fmt.Sprintf("Module call %q does not set the argument %q, which is required because the child module's variable has no default value.", call.Name, name),
+			Pos:      &pos,
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleTreeValidateModuleCallArguments(t *testing.T) {
+	tree, diags := LoadModuleTree("testdata/module-call-arguments")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors loading module tree: %s", diags.Error())
+	}
+
+	type diagSummary struct {
+		Severity DiagSeverity
+		Summary  string
+		Line     int
+	}
+	var got []diagSummary
+	for _, diag := range tree.ValidateModuleCallArguments() {
+		if diag.Pos.Filename != "testdata/module-call-arguments/main.tf" {
+			t.Errorf("diagnostic %q refers to wrong file %s", diag.Summary, diag.Pos.Filename)
+		}
+		got = append(got, diagSummary{diag.Severity, diag.Summary, diag.Pos.Line})
+	}
+	want := []diagSummary{
+		{DiagError, "Unsupported argument", 18},
+		{DiagError, "Invalid value for module argument", 18},
+		{DiagError, "Invalid value for module argument", 18},
+		{DiagError, "Invalid value for module argument", 18},
+		{DiagError, "Missing required argument", 18},
+		{DiagError, "Missing required argument", 30},
+		{DiagError, "Missing required argument", 30},
+		{DiagError, "Missing required argument", 30},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+}
@@ -0,0 +1,397 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ModuleSource is the parsed form of the "source" argument of a module call.
+//
+// The concrete type is one of ModuleSourceLocal, ModuleSourceRegistry or
+// ModuleSourceRemote.
+type ModuleSource interface {
+	moduleSource()
+
+	// String returns a string representation of the source address, which
+	// can be parsed again to produce an equivalent address.
+	String() string
+}
+
+// ModuleSourceLocal is a module source address that refers to a directory
+// relative to the calling module, like "./modules/network".
+type ModuleSourceLocal struct {
+	Path string `json:"path"`
+}
+
+func (s ModuleSourceLocal) moduleSource() {}
+
+func (s ModuleSourceLocal) String() string {
+	return s.Path
+}
+
+// MarshalJSON implements encoding/json.Marshaler.
+func (s ModuleSourceLocal) MarshalJSON() ([]byte, error) {
+	type raw ModuleSourceLocal
+	return marshalModuleSource("local", raw(s))
+}
+
+// ModuleSourceRegistry is a module source address that refers to a module
+// package in a module registry, like "hashicorp/consul/aws".
+type ModuleSourceRegistry struct {
+	// Host is the hostname of the registry. It is "registry.terraform.io"
+	// if the address doesn't include an explicit hostname.
+	Host      string `json:"host"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	System    string `json:"system"`
+
+	// Subdir is the path of a directory within the module package that
+	// contains the module, if any.
+	Subdir string `json:"subdir,omitempty"`
+}
+
+// DefaultModuleRegistryHost is the hostname used for registry module source
+// addresses that don't include an explicit hostname.
+const DefaultModuleRegistryHost = "registry.terraform.io"
+
+func (s ModuleSourceRegistry) moduleSource() {}
+
+func (s ModuleSourceRegistry) String() string {
+	ret := fmt.Sprintf("%s/%s/%s", s.Namespace, s.Name, s.System)
+	if s.Host != DefaultModuleRegistryHost {
+		ret = s.Host + "/" + ret
+	}
+	if s.Subdir != "" {
+		ret += "//" + s.Subdir
+	}
+	return ret
+}
+
+// MarshalJSON implements encoding/json.Marshaler.
+func (s ModuleSourceRegistry) MarshalJSON() ([]byte, error) {
+	type raw ModuleSourceRegistry
+	return marshalModuleSource("registry", raw(s))
+}
+
+// ModuleSourceRemote is a module source address that refers to a module
+// package that is downloaded directly from a remote location, like a git
+// repository or an archive in an S3 bucket.
+type ModuleSourceRemote struct {
+	// Getter is the mechanism used to download the package: one of "git",
+	// "hg", "s3", "gcs" or "http".
+	Getter string `json:"getter"`
+
+	// ForcedGetter is true if the address selected Getter explicitly with
+	// a prefix like "git::", rather than it being detected from the URL.
+	ForcedGetter bool `json:"forced_getter,omitempty"`
+
+	// URL is the location of the package, excluding any subdirectory and
+	// the "ref" query argument. Shorthand addresses like
+	// "github.com/hashicorp/example" are expanded to full URLs.
+	URL string `json:"url"`
+
+	// Ref is the value of the "ref" query argument, which selects a branch,
+	// tag or commit for the "git" and "hg" getters.
+	Ref string `json:"ref,omitempty"`
+
+	// Subdir is the path of a directory within the package that contains
+	// the module, if any.
+	Subdir string `json:"subdir,omitempty"`
+}
+
+func (s ModuleSourceRemote) moduleSource() {}
+
+func (s ModuleSourceRemote) String() string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		// Should never happen because we only produce valid URLs.
+		return s.URL
+	}
+	if s.Subdir != "" {
+		u.Path += "//" + s.Subdir
+	}
+	if s.Ref != "" {
+		query := u.Query()
+		query.Set("ref", s.Ref)
+		u.RawQuery = query.Encode()
+	}
+	ret := u.String()
+	if s.ForcedGetter || impliedModuleSourceGetter(u.Scheme) != s.Getter {
+		// Shorthand addresses were expanded into URLs that would otherwise
+		// select a different getter.
+		ret = s.Getter + "::" + ret
+	}
+	return ret
+}
+
+// MarshalJSON implements encoding/json.Marshaler.
+func (s ModuleSourceRemote) MarshalJSON() ([]byte, error) {
+	type raw ModuleSourceRemote
+	return marshalModuleSource("remote", raw(s))
+}
+
+// marshalModuleSource produces the JSON representation of the given module
+// source address, with an additional "type" property identifying which kind
+// of address it is.
+func marshalModuleSource(typeName string, s interface{}) ([]byte, error) {
+	src, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(src, &obj); err != nil {
+		return nil, err
+	}
+	obj["type"] = typeName
+	return json.Marshal(obj)
+}
+
+// moduleSourceGetters are the getters that can be forced with a prefix like
+// "git::".
+var moduleSourceGetters = map[string]bool{
+	"git":   true,
+	"hg":    true,
+	"s3":    true,
+	"gcs":   true,
+	"http":  true,
+	"https": true,
+}
+
+// ParseModuleSource parses the given module source address, returning an
+// error if it is not valid.
+//
+// The address forms are those documented for the "source" argument of
+// module blocks in the Terraform language.
+func ParseModuleSource(raw string) (ModuleSource, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("a module source address must not be empty")
+	}
+
+	if isLocalSourceAddr(raw) {
+		return ModuleSourceLocal{Path: raw}, nil
+	}
+
+	if strings.Contains(raw, "::") {
+		return parseModuleSourceRemote(raw)
+	}
+
+	if registry, ok, err := parseModuleSourceRegistry(raw); ok {
+		return registry, err
+	}
+
+	return parseModuleSourceRemote(raw)
+}
+
+var (
+	// registryNamePartRe matches the namespace, name and system parts of
+	// a registry module source address.
+	registryNamePartRe = regexp.MustCompile(`^[0-9A-Za-z](?:[0-9A-Za-z_-]{0,62}[0-9A-Za-z])?$`)
+	registrySystemRe   = regexp.MustCompile(`^[0-9a-z]{1,64}$`)
+	hostnameRe         = regexp.MustCompile(`^[0-9A-Za-z](?:[0-9A-Za-z.-]*[0-9A-Za-z])?(?::[0-9]+)?$`)
+)
+
+// parseModuleSourceRegistry attempts to parse the given address as a
+// registry address. The second return value is false if the address is not
+// shaped like a registry address at all, in which case it should be parsed
+// as a remote address instead.
+func parseModuleSourceRegistry(raw string) (ModuleSource, bool, error) {
+	pkg, subdir := splitModuleSourceSubdir(raw)
+	parts := strings.Split(pkg, "/")
+
+	ret := ModuleSourceRegistry{
+		Host:   DefaultModuleRegistryHost,
+		Subdir: subdir,
+	}
+	switch len(parts) {
+	case 3:
+	case 4:
+		ret.Host = parts[0]
+		parts = parts[1:]
+		if !hostnameRe.MatchString(ret.Host) {
+			return nil, false, nil
+		}
+	default:
+		return nil, false, nil
+	}
+
+	// These hosts are handled by special shorthand rules for remote
+	// addresses, and so can never be registry hosts.
+	switch host := strings.ToLower(ret.Host); {
+	case host == "github.com", host == "bitbucket.org", host == "www.googleapis.com", s3HostRe.MatchString(host):
+		return nil, false, nil
+	}
+	if strings.Contains(parts[0], ".") && ret.Host == DefaultModuleRegistryHost {
+		// Looks like a hostname for some other kind of address.
+		return nil, false, nil
+	}
+
+	ret.Namespace, ret.Name, ret.System = parts[0], parts[1], parts[2]
+	if !registryNamePartRe.MatchString(ret.Namespace) || !registryNamePartRe.MatchString(ret.Name) {
+		return nil, true, fmt.Errorf("invalid registry module source address %q: the namespace and module name may contain only letters, digits, dashes and underscores", raw)
+	}
+	if !registrySystemRe.MatchString(ret.System) {
+		return nil, true, fmt.Errorf("invalid registry module source address %q: the target system name may contain only lowercase letters and digits", raw)
+	}
+	return ret, true, nil
+}
+
+var (
+	scpLikeGitRe = regexp.MustCompile(`^([A-Za-z0-9_.-]+)@([A-Za-z0-9_.-]+):(.+)$`)
+	s3HostRe     = regexp.MustCompile(`^(?:[^/]+\.)?s3[.-](?:[a-z0-9-]+\.)?amazonaws\.com$`)
+)
+
+func parseModuleSourceRemote(raw string) (ModuleSource, error) {
+	ret := ModuleSourceRemote{}
+
+	addr := raw
+	if idx := strings.Index(addr, "::"); idx != -1 {
+		getter := addr[:idx]
+		if !moduleSourceGetters[getter] {
+			return nil, fmt.Errorf("invalid module source address %q: unsupported getter %q", raw, getter)
+		}
+		if getter == "https" {
+			getter = "http"
+		}
+		ret.Getter = getter
+		ret.ForcedGetter = true
+		addr = addr[idx+2:]
+	}
+
+	addr, ret.Subdir = splitModuleSourceSubdir(addr)
+
+	if match := scpLikeGitRe.FindStringSubmatch(addr); match != nil && !strings.Contains(addr, "://") {
+		// The scp-like syntax that git accepts for SSH, like
+		// "git@github.com:hashicorp/example.git".
+		if ret.Getter == "" {
+			ret.Getter = "git"
+		}
+		addr = fmt.Sprintf("ssh://%s@%s/%s", match[1], match[2], match[3])
+	}
+
+	if !strings.Contains(addr, "://") {
+		// Shorthand addresses without a scheme are recognized by their
+		// hostname.
+		host := addr
+		if idx := strings.IndexAny(host, "/?"); idx != -1 {
+			host = host[:idx]
+		}
+		switch {
+		case host == "github.com" || host == "bitbucket.org":
+			if ret.Getter == "" {
+				ret.Getter = "git"
+			}
+			u, err := url.Parse("https://" + addr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid module source address %q: %s", raw, err)
+			}
+			if ret.Getter == "git" && !strings.HasSuffix(u.Path, ".git") {
+				u.Path += ".git"
+			}
+			addr = u.String()
+		case s3HostRe.MatchString(host):
+			if ret.Getter == "" {
+				ret.Getter = "s3"
+			}
+			addr = "https://" + addr
+		case host == "www.googleapis.com" && strings.HasPrefix(addr, host+"/storage/"):
+			if ret.Getter == "" {
+				ret.Getter = "gcs"
+			}
+			addr = "https://" + addr
+		default:
+			return nil, fmt.Errorf("invalid module source address %q: must be a local path starting with \"./\" or \"../\", a registry address like \"namespace/name/system\", or a remote URL", raw)
+		}
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid module source address %q: %s", raw, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid module source address %q: URL has no hostname", raw)
+	}
+
+	if ret.Getter == "" {
+		ret.Getter = impliedModuleSourceGetter(u.Scheme)
+		if ret.Getter == "" {
+			return nil, fmt.Errorf("invalid module source address %q: unsupported URL scheme %q", raw, u.Scheme)
+		}
+	}
+
+	query := u.Query()
+	if ref := query.Get("ref"); ref != "" {
+		if ret.Getter != "git" && ret.Getter != "hg" {
+			return nil, fmt.Errorf("invalid module source address %q: the \"ref\" argument is only supported for git and hg sources", raw)
+		}
+		ret.Ref = ref
+		query.Del("ref")
+		u.RawQuery = query.Encode()
+	}
+	ret.URL = u.String()
+
+	return ret, nil
+}
+
+// isLocalSourceAddr returns true if the given module source address refers
+// to a directory relative to the calling module.
+func isLocalSourceAddr(addr string) bool {
+	for _, prefix := range []string{"./", "../", ".\\", "..\\"} {
+		if strings.HasPrefix(addr, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// impliedModuleSourceGetter returns the getter selected by a remote module
+// source URL with the given scheme, or an empty string if the scheme is not
+// supported.
+func impliedModuleSourceGetter(scheme string) string {
+	switch scheme {
+	case "git+ssh", "ssh":
+		return "git"
+	case "s3":
+		return "s3"
+	case "gs":
+		return "gcs"
+	case "http", "https":
+		return "http"
+	default:
+		return ""
+	}
+}
+
+// splitModuleSourceSubdir splits the given address into the address of the
+// package and the subdirectory within it, which is separated from the
+// package address by a double slash.
+func splitModuleSourceSubdir(addr string) (string, string) {
+	// The scheme separator also contains a double slash, so we must skip
+	// over it before looking.
+	offset := 0
+	if idx := strings.Index(addr, "://"); idx != -1 {
+		offset = idx + 3
+	}
+
+	idx := strings.Index(addr[offset:], "//")
+	if idx == -1 {
+		return addr, ""
+	}
+	idx += offset
+
+	subdir := addr[idx+2:]
+	addr = addr[:idx]
+
+	// Any query string belongs to the package address, rather than the
+	// subdirectory.
+	if qIdx := strings.Index(subdir, "?"); qIdx != -1 {
+		addr += subdir[qIdx:]
+		subdir = subdir[:qIdx]
+	}
+	return addr, subdir
+}
@@ -0,0 +1,146 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseModuleSource(t *testing.T) {
+	tests := map[string]struct {
+		want    ModuleSource
+		wantErr string
+	}{
+		"./modules/network": {
+			want: ModuleSourceLocal{Path: "./modules/network"},
+		},
+		"../elsewhere": {
+			want: ModuleSourceLocal{Path: "../elsewhere"},
+		},
+		"hashicorp/consul/aws": {
+			want: ModuleSourceRegistry{
+				Host:      "registry.terraform.io",
+				Namespace: "hashicorp",
+				Name:      "consul",
+				System:    "aws",
+			},
+		},
+		"app.terraform.io/example-corp/k8s-cluster/azurerm//modules/nodes": {
+			want: ModuleSourceRegistry{
+				Host:      "app.terraform.io",
+				Namespace: "example-corp",
+				Name:      "k8s-cluster",
+				System:    "azurerm",
+				Subdir:    "modules/nodes",
+			},
+		},
+		"hashicorp/consul/AWS": {
+			wantErr: `invalid registry module source address "hashicorp/consul/AWS": the target system name may contain only lowercase letters and digits`,
+		},
+		"github.com/hashicorp/example?ref=v1.2.0": {
+			want: ModuleSourceRemote{
+				Getter: "git",
+				URL:    "https://github.com/hashicorp/example.git",
+				Ref:    "v1.2.0",
+			},
+		},
+		"git@github.com:hashicorp/example.git//modules/foo": {
+			want: ModuleSourceRemote{
+				Getter: "git",
+				URL:    "ssh://git@github.com/hashicorp/example.git",
+				Subdir: "modules/foo",
+			},
+		},
+		"git::https://example.com/vpc.git//network?ref=v1.2.0": {
+			want: ModuleSourceRemote{
+				Getter:       "git",
+				ForcedGetter: true,
+				URL:          "https://example.com/vpc.git",
+				Ref:          "v1.2.0",
+				Subdir:       "network",
+			},
+		},
+		"hg::http://example.com/vpc.hg?ref=default": {
+			want: ModuleSourceRemote{
+				Getter:       "hg",
+				ForcedGetter: true,
+				URL:          "http://example.com/vpc.hg",
+				Ref:          "default",
+			},
+		},
+		"https://example.com/vpc-module.zip?archive=zip": {
+			want: ModuleSourceRemote{
+				Getter: "http",
+				URL:    "https://example.com/vpc-module.zip?archive=zip",
+			},
+		},
+		"s3::https://s3-eu-west-1.amazonaws.com/examplecorp-terraform-modules/vpc.zip": {
+			want: ModuleSourceRemote{
+				Getter:       "s3",
+				ForcedGetter: true,
+				URL:          "https://s3-eu-west-1.amazonaws.com/examplecorp-terraform-modules/vpc.zip",
+			},
+		},
+		"examplecorp-terraform-modules.s3.amazonaws.com/vpc/network.zip": {
+			want: ModuleSourceRemote{
+				Getter: "s3",
+				URL:    "https://examplecorp-terraform-modules.s3.amazonaws.com/vpc/network.zip",
+			},
+		},
+		"gcs::https://www.googleapis.com/storage/v1/modules/foomodule.zip": {
+			want: ModuleSourceRemote{
+				Getter:       "gcs",
+				ForcedGetter: true,
+				URL:          "https://www.googleapis.com/storage/v1/modules/foomodule.zip",
+			},
+		},
+		"https://example.com/vpc-module.zip?ref=v1": {
+			wantErr: `invalid module source address "https://example.com/vpc-module.zip?ref=v1": the "ref" argument is only supported for git and hg sources`,
+		},
+		"foo::https://example.com/": {
+			wantErr: `invalid module source address "foo::https://example.com/": unsupported getter "foo"`,
+		},
+		"modules/network": {
+			wantErr: `invalid module source address "modules/network": must be a local path starting with "./" or "../", a registry address like "namespace/name/system", or a remote URL`,
+		},
+	}
+
+	for raw, test := range tests {
+		t.Run(raw, func(t *testing.T) {
+			got, err := ParseModuleSource(raw)
+			if test.wantErr != "" {
+				if err == nil {
+					t.Fatalf("unexpected success; want error: %s", test.wantErr)
+				}
+				if got := err.Error(); got != test.wantErr {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+
+			// The string representation must parse to an equivalent address,
+			// although it may need to force a getter that was previously
+			// implied by a shorthand address.
+			again, err := ParseModuleSource(got.String())
+			if err != nil {
+				t.Fatalf("failed to parse string representation %q: %s", got.String(), err)
+			}
+			if remote, ok := again.(ModuleSourceRemote); ok {
+				remote.ForcedGetter = got.(ModuleSourceRemote).ForcedGetter
+				again = remote
+			}
+			if diff := cmp.Diff(got, again); diff != "" {
+				t.Errorf("string representation %q does not round-trip\n%s", got.String(), diff)
+			}
+		})
+	}
+}
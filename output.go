@@ -3,10 +3,78 @@
 
 package terraparse
 
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
 // Output represents a single output from a Terraform module.
 type Output struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description,omitempty"`
-	Sensitive   bool      `json:"sensitive,omitempty"`
-	Pos         SourcePos `json:"pos"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Sensitive   bool        `json:"sensitive,omitempty"`
+	Ephemeral   bool        `json:"ephemeral,omitempty"`
+	Value       *Expression `json:"value,omitempty"`
+
+	// DependsOn are the explicit dependencies given in the output's
+	// depends_on argument.
+	DependsOn []Reference `json:"depends_on,omitempty"`
+
+	// Preconditions are the custom conditions that must hold before the
+	// output value is evaluated.
+	Preconditions []*CheckRule `json:"preconditions,omitempty"`
+
+	Pos SourcePos `json:"pos"`
+	Provenance
+}
+
+// decodeOutputBlock decodes an "output" block from the given file.
+func decodeOutputBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*Output, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(outputSchema)
+
+	name := block.Labels[0]
+	o := &Output{
+		Name:       name,
+		Pos:        sourcePosHCL(block.DefRange, opts),
+		Provenance: newProvenance(block.DefRange, content.Attributes, nil, opts),
+	}
+
+	if attr, defined := content.Attributes["value"]; defined {
+		o.Value = newExpression(attr.Expr, file, opts)
+	}
+
+	if attr, defined := content.Attributes["description"]; defined {
+		var description string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &description)
+		diags = append(diags, valDiags...)
+		o.Description = description
+	}
+
+	if attr, defined := content.Attributes["sensitive"]; defined {
+		var sensitive bool
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &sensitive)
+		diags = append(diags, valDiags...)
+		o.Sensitive = sensitive
+	}
+
+	if attr, defined := content.Attributes["ephemeral"]; defined {
+		var ephemeral bool
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ephemeral)
+		diags = append(diags, valDiags...)
+		o.Ephemeral = ephemeral
+	}
+
+	if attr, defined := content.Attributes["depends_on"]; defined {
+		refs, refsDiags := decodeDependsOn(attr, opts)
+		diags = append(diags, refsDiags...)
+		o.DependsOn = refs
+	}
+
+	for _, block := range content.Blocks {
+		cr, crDiags := decodeCheckRule(block, file, opts)
+		diags = append(diags, crDiags...)
+		o.Preconditions = append(o.Preconditions, cr)
+	}
+
+	return o, diags
 }
@@ -0,0 +1,459 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
+// LoadModuleFromOverrideFile reads the given override file, interprets it and
+// merges it into the given Module, which must already contain the contents
+// of all of the module's primary files.
+//
+// Following Terraform's rules for override files, each block in an override
+// file changes only the arguments that it sets in the block of the same kind
+// and name in a primary file, and its nested blocks replace all of the
+// existing nested blocks of the same type. Override files can't declare new
+// objects, so it's an error for a block to have no counterpart to override.
+func LoadModuleFromOverrideFile(file *hcl.File, mod *Module) hcl.Diagnostics {
+	return loadModuleFromOverrideFile(file, mod, newDecodeOptions(LoadOptions{}))
+}
+
+func loadModuleFromOverrideFile(file *hcl.File, mod *Module, opts *decodeOptions) hcl.Diagnostics {
+	content, _, diags := file.Body.PartialContent(rootSchema)
+
+	// The override file can change both the required_providers entries and
+	// the legacy version constraints of the provider configurations, so we
+	// rebuild the provider requirements from both once it's merged.
+	mod.removeProviderConfigVersions()
+	defer mod.addProviderConfigVersions()
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+
+		case "terraform":
+			diags = append(diags, mod.mergeTerraformBlock(block, file, opts)...)
+
+		case "variable":
+			name := block.Labels[0]
+			base, exists := mod.Variables[name]
+			if !exists {
+				diags = append(diags, missingOverrideBase(block.DefRange,
+					"Missing base variable declaration to override",
+					fmt.Sprintf("There is no variable named %q. An override file can only override a variable that was already declared in a primary configuration file.", name),
+					opts,
+				))
+				continue
+			}
+			v, vDiags := decodeVariableBlock(block, file, opts)
+			diags = append(diags, vDiags...)
+			base.merge(v, overrideContent(block, variableSchema))
+
+		case "output":
+			name := block.Labels[0]
+			base, exists := mod.Outputs[name]
+			if !exists {
+				diags = append(diags, missingOverrideBase(block.DefRange,
+					"Missing base output definition to override",
+					fmt.Sprintf("There is no output named %q. An override file can only override an output that was already defined in a primary configuration file.", name),
+					opts,
+				))
+				continue
+			}
+			o, oDiags := decodeOutputBlock(block, file, opts)
+			diags = append(diags, oDiags...)
+			base.merge(o, overrideContent(block, outputSchema))
+
+		case "locals":
+			attrs, attrDiags := block.Body.JustAttributes()
+			diags = append(diags, attrDiags...)
+
+			for _, name := range sortedKeys(attrs) {
+				attr := attrs[name]
+				if _, exists := mod.Locals[name]; !exists {
+					diags = append(diags, missingOverrideBase(attr.NameRange,
+						"Missing base local value definition to override",
+						fmt.Sprintf("There is no local value named %q. An override file can only override a local value that was already defined in a primary configuration file.", name),
+						opts,
+					))
+					continue
+				}
+				mod.Locals[name] = &Local{
+					Name:       name,
+					Expression: newExpression(attr.Expr, file, opts),
+					Pos:        sourcePosHCL(attr.Range, opts),
+				}
+			}
+
+		case "provider":
+			pc, pcDiags := decodeProviderConfigBlock(block, file, opts)
+			diags = append(diags, pcDiags...)
+			base, exists := mod.ProviderConfigs[pc.MapKey()]
+			if !exists {
+				diags = append(diags, missingOverrideBase(block.DefRange,
+					"Missing base provider configuration for override",
+					fmt.Sprintf("There is no provider configuration for %s. An override file can only override a provider configuration that was already defined in a primary configuration file.", pc.MapKey()),
+					opts,
+				))
+				continue
+			}
+			if pc.Version != "" {
+				base.Version = pc.Version
+			}
+			base.Attributes = mergeAttributes(base.Attributes, pc.Attributes)
+			base.Blocks = mergeBlocks(base.Blocks, pc.Blocks)
+			base.Provenance.merge(pc.Provenance)
+
+		case "resource", "data":
+			r, rDiags := decodeResourceBlock(block, file, opts)
+			diags = append(diags, rDiags...)
+
+			resources, summary := mod.ManagedResources, "Missing resource to override"
+			if r.Mode == DataResourceMode {
+				resources, summary = mod.DataResources, "Missing data resource to override"
+			}
+			base, exists := resources[r.MapKey()]
+			if !exists {
+				diags = append(diags, missingOverrideBase(block.DefRange, summary,
+					fmt.Sprintf("There is no %s block for %s. An override file can only override a resource block defined in a primary configuration file.", block.Type, r.MapKey()),
+					opts,
+				))
+				continue
+			}
+			base.merge(r, overrideContent(block, resourceSchema))
+
+		case "module":
+			name := block.Labels[0]
+			base, exists := mod.ModuleCalls[name]
+			if !exists {
+				diags = append(diags, missingOverrideBase(block.DefRange,
+					"Missing module call to override",
+					fmt.Sprintf("There is no module call named %q. An override file can only override a module call that was defined in a primary configuration file.", name),
+					opts,
+				))
+				continue
+			}
+			mc, mcDiags := decodeModuleCallBlock(block, file, opts)
+			diags = append(diags, mcDiags...)
+			base.merge(mc, overrideContent(block, moduleCallSchema))
+
+		case "check", "moved", "removed", "import":
+			diags = append(diags, opts.diagnostic("unsupported-override", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Cannot override %q blocks", block.Type),
+				Detail:   fmt.Sprintf("A %s block can appear only in a primary configuration file, not in an override file.", block.Type),
+				Subject:  block.DefRange.Ptr(),
+			}))
+
+		default:
+			// Should never happen because our cases above should be
+			// exhaustive for our schema.
+			panic(fmt.Errorf("unhandled block type %q", block.Type))
+		}
+	}
+
+	return diags
+}
+
+// removeProviderConfigVersions removes the legacy version constraints of the
+// module's provider configurations from its provider requirements, so that
+// addProviderConfigVersions can add them back after the configurations have
+// changed.
+func (m *Module) removeProviderConfigVersions() {
+	for _, key := range sortedKeys(m.ProviderConfigs) {
+		pc := m.ProviderConfigs[key]
+		req, exists := m.RequiredProviders[pc.Name]
+		if pc.Version == "" || !exists {
+			continue
+		}
+		for i, constraint := range req.VersionConstraints {
+			if constraint == pc.Version {
+				req.VersionConstraints = append(req.VersionConstraints[:i:i], req.VersionConstraints[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// addProviderConfigVersions adds the legacy version constraints of the
+// module's provider configurations to its provider requirements.
+func (m *Module) addProviderConfigVersions() {
+	for _, key := range sortedKeys(m.ProviderConfigs) {
+		pc := m.ProviderConfigs[key]
+		if pc.Version == "" {
+			continue
+		}
+		if _, exists := m.RequiredProviders[pc.Name]; !exists {
+			m.RequiredProviders[pc.Name] = &ProviderRequirement{}
+		}
+		m.RequiredProviders[pc.Name].VersionConstraints = append(m.RequiredProviders[pc.Name].VersionConstraints, pc.Version)
+	}
+}
+
+// mergeTerraformBlock merges a "terraform" block from an override file into
+// the receiver. Each setting in the override block replaces the same
+// setting from the primary files, with a backend and a cloud block each
+// replacing the other, and each provider in required_providers replacing
+// the existing requirement for that provider.
+func (m *Module) mergeTerraformBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) hcl.Diagnostics {
+	content, _, diags := block.Body.PartialContent(terraformBlockSchema)
+
+	if attr, defined := content.Attributes["required_version"]; defined {
+		var version string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &version)
+		diags = append(diags, valDiags...)
+		if !valDiags.HasErrors() {
+			m.RequiredCore = []string{version}
+		}
+	}
+
+	if attr, defined := content.Attributes["experiments"]; defined {
+		experiments, expDiags := decodeExperiments(attr, opts)
+		diags = append(diags, expDiags...)
+		m.Experiments = experiments
+	}
+
+	for _, innerBlock := range content.Blocks {
+		switch innerBlock.Type {
+		case "backend":
+			b, bDiags := decodeBackendBlock(innerBlock, file, opts)
+			diags = append(diags, bDiags...)
+			m.Backend = b
+			m.Cloud = nil
+
+		case "cloud":
+			c, cDiags := decodeCloudBlock(innerBlock, opts)
+			diags = append(diags, cDiags...)
+			m.Cloud = c
+			m.Backend = nil
+
+		case "provider_meta":
+			pm, pmDiags := decodeProviderMetaBlock(innerBlock, file, opts)
+			diags = append(diags, pmDiags...)
+			m.ProviderMeta[pm.Provider] = pm
+
+		case "required_providers":
+			reqs, reqsDiags := decodeRequiredProvidersBlock(innerBlock, opts)
+			diags = append(diags, reqsDiags...)
+			for name, req := range reqs {
+				m.RequiredProviders[name] = req
+			}
+		}
+	}
+
+	return diags
+}
+
+// merge overrides the receiver with the arguments set in the given content
+// of an override block, which was decoded as o.
+func (v *Variable) merge(o *Variable, content *hcl.BodyContent) {
+	v.Provenance.merge(o.Provenance)
+	if _, set := content.Attributes["type"]; set {
+		v.Type = o.Type
+		v.TypeConstraint = o.TypeConstraint
+	}
+	if _, set := content.Attributes["description"]; set {
+		v.Description = o.Description
+	}
+	if _, set := content.Attributes["default"]; set {
+		v.Default = o.Default
+		v.DefaultValue = o.DefaultValue
+		v.Required = false
+	}
+	if _, set := content.Attributes["sensitive"]; set {
+		v.Sensitive = o.Sensitive
+	}
+	if _, set := content.Attributes["nullable"]; set {
+		v.Nullable = o.Nullable
+	}
+	if _, set := content.Attributes["ephemeral"]; set {
+		v.Ephemeral = o.Ephemeral
+	}
+	if len(content.Blocks) != 0 {
+		v.Validations = o.Validations
+	}
+}
+
+// merge overrides the receiver with the arguments set in the given content
+// of an override block, which was decoded as o.
+func (out *Output) merge(o *Output, content *hcl.BodyContent) {
+	out.Provenance.merge(o.Provenance)
+	if _, set := content.Attributes["value"]; set {
+		out.Value = o.Value
+	}
+	if _, set := content.Attributes["description"]; set {
+		out.Description = o.Description
+	}
+	if _, set := content.Attributes["sensitive"]; set {
+		out.Sensitive = o.Sensitive
+	}
+	if _, set := content.Attributes["ephemeral"]; set {
+		out.Ephemeral = o.Ephemeral
+	}
+	if _, set := content.Attributes["depends_on"]; set {
+		out.DependsOn = o.DependsOn
+	}
+	if len(content.Blocks) != 0 {
+		out.Preconditions = o.Preconditions
+	}
+}
+
+// merge overrides the receiver with the arguments and nested blocks set in
+// the given content of an override block, which was decoded as o. The
+// lifecycle block is merged argument by argument, while any other nested
+// blocks replace all existing blocks of the same type.
+func (r *Resource) merge(o *Resource, content *hcl.BodyContent) {
+	r.Provenance.merge(o.Provenance)
+	r.Attributes = mergeAttributes(r.Attributes, o.Attributes)
+	r.Blocks = mergeBlocks(r.Blocks, o.Blocks)
+
+	if _, set := content.Attributes["provider"]; set {
+		r.Provider = o.Provider
+	}
+	// A resource can't use both count and for_each, so setting either one
+	// unsets the other.
+	if _, set := content.Attributes["count"]; set {
+		r.Count = o.Count
+		r.ForEach = nil
+		delete(r.AttributeSources, "for_each")
+	}
+	if _, set := content.Attributes["for_each"]; set {
+		r.ForEach = o.ForEach
+		r.Count = nil
+		delete(r.AttributeSources, "count")
+	}
+	if _, set := content.Attributes["depends_on"]; set {
+		r.DependsOn = o.DependsOn
+	}
+
+	for _, block := range content.Blocks {
+		if r.Lifecycle == nil {
+			r.Lifecycle = o.Lifecycle
+			continue
+		}
+		r.Lifecycle.merge(o.Lifecycle, overrideContent(block, lifecycleSchema))
+	}
+}
+
+// merge overrides the receiver with the arguments set in the given content
+// of an override block, which was decoded as o.
+func (lc *Lifecycle) merge(o *Lifecycle, content *hcl.BodyContent) {
+	if _, set := content.Attributes["create_before_destroy"]; set {
+		lc.CreateBeforeDestroy = o.CreateBeforeDestroy
+	}
+	if _, set := content.Attributes["prevent_destroy"]; set {
+		lc.PreventDestroy = o.PreventDestroy
+	}
+	if _, set := content.Attributes["ignore_changes"]; set {
+		lc.IgnoreChanges = o.IgnoreChanges
+		lc.IgnoreAllChanges = o.IgnoreAllChanges
+	}
+	if _, set := content.Attributes["replace_triggered_by"]; set {
+		lc.ReplaceTriggeredBy = o.ReplaceTriggeredBy
+	}
+	if len(content.Blocks.OfType("precondition")) != 0 {
+		lc.Preconditions = o.Preconditions
+	}
+	if len(content.Blocks.OfType("postcondition")) != 0 {
+		lc.Postconditions = o.Postconditions
+	}
+}
+
+// merge overrides the receiver with the arguments set in the given content
+// of an override block, which was decoded as o. Arguments for the child
+// module's input variables are merged one by one.
+func (mc *ModuleCall) merge(o *ModuleCall, content *hcl.BodyContent) {
+	mc.Provenance.merge(o.Provenance)
+	mc.Attributes = mergeAttributes(mc.Attributes, o.Attributes)
+
+	if _, set := content.Attributes["source"]; set {
+		mc.Source = o.Source
+	}
+	if _, set := content.Attributes["version"]; set {
+		mc.Version = o.Version
+	}
+	if _, set := content.Attributes["providers"]; set {
+		mc.Providers = o.Providers
+	}
+	if _, set := content.Attributes["count"]; set {
+		mc.Count = o.Count
+		mc.ForEach = nil
+		delete(mc.AttributeSources, "for_each")
+	}
+	if _, set := content.Attributes["for_each"]; set {
+		mc.ForEach = o.ForEach
+		mc.Count = nil
+		delete(mc.AttributeSources, "count")
+	}
+	if _, set := content.Attributes["depends_on"]; set {
+		mc.DependsOn = o.DependsOn
+	}
+}
+
+// overrideContent returns the content of the given override block according
+// to the given schema, for determining which arguments and nested blocks it
+// sets. Any problems with the block are reported when it is decoded, so this
+// ignores diagnostics.
+func overrideContent(block *hcl.Block, schema *hcl.BodySchema) *hcl.BodyContent {
+	content, _, _ := block.Body.PartialContent(schema)
+	return content
+}
+
+// mergeAttributes returns the given base attributes with each of the given
+// override attributes replacing the base attribute of the same name.
+func mergeAttributes(base, override Attributes) Attributes {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(Attributes, len(base)+len(override))
+	for name, attr := range base {
+		merged[name] = attr
+	}
+	for name, attr := range override {
+		merged[name] = attr
+	}
+	return merged
+}
+
+// mergeBlocks returns the given base blocks with all of the blocks of each
+// type that appears in the given override blocks replaced by the override
+// blocks of that type. A dynamic block counts as a block of the type named
+// by its label, so it replaces and is replaced by static blocks of that type.
+func mergeBlocks(base, override []*Block) []*Block {
+	if len(override) == 0 {
+		return base
+	}
+	replaced := make(map[string]bool)
+	for _, block := range override {
+		replaced[overrideBlockType(block)] = true
+	}
+	var merged []*Block
+	for _, block := range base {
+		if !replaced[overrideBlockType(block)] {
+			merged = append(merged, block)
+		}
+	}
+	return append(merged, override...)
+}
+
+// overrideBlockType returns the type of the blocks that the given block
+// produces.
+func overrideBlockType(block *Block) string {
+	if block.Type == "dynamic" && len(block.Labels) > 0 {
+		return block.Labels[0]
+	}
+	return block.Type
+}
+
+func missingOverrideBase(rng hcl.Range, summary, detail string, opts *decodeOptions) *hcl.Diagnostic {
+	return opts.diagnostic("missing-override-base", &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  rng.Ptr(),
+	})
+}
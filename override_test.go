@@ -0,0 +1,198 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadModuleOverrideMerge(t *testing.T) {
+	mod, diags := LoadModule("testdata/override-merge")
+
+	type diagSummary struct {
+		Severity DiagSeverity
+		Summary  string
+		Line     int
+	}
+	var gotDiags []diagSummary
+	for _, diag := range diags {
+		gotDiags = append(gotDiags, diagSummary{diag.Severity, diag.Summary, diag.Pos.Line})
+	}
+	wantDiags := []diagSummary{
+		{DiagError, "Missing resource to override", 1},
+		{DiagError, "Missing module call to override", 5},
+		{DiagError, "Missing base local value definition to override", 10},
+		{DiagError, `Cannot override "moved" blocks`, 13},
+	}
+	if diff := cmp.Diff(wantDiags, gotDiags); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+
+	v := mod.Variables["instance_type"]
+	if v.Description != "The instance type, overridden" || v.Default != "t3.micro" || v.Required || v.Type != "string" {
+		t.Errorf("wrong merged variable %#v", v)
+	}
+
+	o := mod.Outputs["instance_ids"]
+	if !o.Sensitive || o.Description != "The instance IDs" || o.Value == nil {
+		t.Errorf("wrong merged output %#v", o)
+	}
+
+	if got := mod.Locals["env"].Expression.Source; got != `"prod"` {
+		t.Errorf("wrong merged local value %s", got)
+	}
+
+	r := mod.ManagedResources["aws_instance.web"]
+	gotAttrs := map[string]string{}
+	for name, attr := range r.Attributes {
+		gotAttrs[name] = attr.Value.AsString()
+	}
+	wantAttrs := map[string]string{
+		"ami":           "ami-override",
+		"instance_type": "var.instance_type",
+	}
+	if diff := cmp.Diff(wantAttrs, gotAttrs); diff != "" {
+		t.Errorf("wrong merged resource attributes\n%s", diff)
+	}
+	var gotBlocks []string
+	for _, block := range r.Blocks {
+		gotBlocks = append(gotBlocks, block.Type+" "+block.Pos.Filename)
+	}
+	wantBlocks := []string{
+		"root_block_device testdata/override-merge/main.tf",
+		"ebs_block_device testdata/override-merge/main_override.tf",
+	}
+	if diff := cmp.Diff(wantBlocks, gotBlocks); diff != "" {
+		t.Errorf("wrong merged resource blocks\n%s", diff)
+	}
+
+	// Dynamic blocks replace, and are replaced by, blocks of the type named
+	// by their label.
+	gotBlocks = nil
+	for _, block := range mod.ManagedResources["aws_security_group.web"].Blocks {
+		gotBlocks = append(gotBlocks, block.Type+" "+strings.Join(block.Labels, " ")+" "+block.Pos.Filename)
+	}
+	wantBlocks = []string{
+		"dynamic egress testdata/override-merge/main.tf",
+		"dynamic ingress testdata/override-merge/main_override.tf",
+	}
+	if diff := cmp.Diff(wantBlocks, gotBlocks); diff != "" {
+		t.Errorf("wrong merged dynamic blocks\n%s", diff)
+	}
+	if r.Count != nil || r.ForEach == nil {
+		t.Errorf("for_each in override did not replace count")
+	}
+	if lc := r.Lifecycle; !lc.CreateBeforeDestroy || !lc.PreventDestroy || len(lc.IgnoreChanges) != 1 {
+		t.Errorf("wrong merged lifecycle %#v", lc)
+	}
+
+	mc := mod.ModuleCalls["network"]
+	if mc.Source != "hashicorp/network/aws" || mc.Version != "2.0.0" {
+		t.Errorf("wrong merged module call source %q and version %q", mc.Source, mc.Version)
+	}
+	if got := mc.Attributes["cidr_block"].Value.AsString(); got != "10.1.0.0/16" {
+		t.Errorf("wrong merged module call argument %s", got)
+	}
+	if mc.Attributes["name"] == nil {
+		t.Errorf("module call argument not set in the override was removed")
+	}
+
+	pc := mod.ProviderConfigs["aws"]
+	if got := pc.Attributes["region"].Value.AsString(); got != "eu-west-1" || pc.Attributes["profile"] == nil || len(pc.Blocks) != 1 {
+		t.Errorf("wrong merged provider configuration %#v", pc)
+	}
+
+	if diff := cmp.Diff([]string{"~> 5.0"}, mod.RequiredProviders["aws"].VersionConstraints); diff != "" {
+		t.Errorf("wrong provider version constraints\n%s", diff)
+	}
+	// The override's legacy version argument replaces the one in the
+	// overridden provider block, rather than adding to it.
+	if got := mod.ProviderConfigs["google"].Version; got != "~> 5.0" {
+		t.Errorf("wrong merged provider version %q", got)
+	}
+	if diff := cmp.Diff([]string{"~> 5.0"}, mod.RequiredProviders["google"].VersionConstraints); diff != "" {
+		t.Errorf("wrong legacy provider version constraints\n%s", diff)
+	}
+	if mod.Backend != nil || mod.Cloud == nil {
+		t.Errorf("cloud block in override did not replace backend")
+	}
+}
+
+func TestLoadModuleOverrideProvenance(t *testing.T) {
+	mod, _ := LoadModule("testdata/override-merge")
+	const (
+		primary  = "testdata/override-merge/main.tf"
+		override = "testdata/override-merge/main_override.tf"
+	)
+
+	r := mod.ManagedResources["aws_instance.web"]
+	wantDefs := []SourcePos{
+		{Filename: primary, Line: 37},
+		{Filename: override, Line: 30},
+	}
+	if diff := cmp.Diff(wantDefs, r.Definitions); diff != "" {
+		t.Errorf("wrong resource definitions\n%s", diff)
+	}
+	wantSources := map[string]SourcePos{
+		"ami":           {Filename: override, Line: 32},
+		"for_each":      {Filename: override, Line: 31},
+		"instance_type": {Filename: primary, Line: 40},
+	}
+	if diff := cmp.Diff(wantSources, r.AttributeSources); diff != "" {
+		t.Errorf("wrong resource attribute sources\n%s", diff)
+	}
+
+	tests := map[string]struct {
+		provenance Provenance
+		attr       string
+		want       string
+	}{
+		"variable overridden":     {mod.Variables["instance_type"].Provenance, "description", override},
+		"variable not overridden": {mod.Variables["instance_type"].Provenance, "default", primary},
+		"output":                  {mod.Outputs["instance_ids"].Provenance, "sensitive", override},
+		"module call version":     {mod.ModuleCalls["network"].Provenance, "version", override},
+		"module call source":      {mod.ModuleCalls["network"].Provenance, "source", primary},
+		"module call argument":    {mod.ModuleCalls["network"].Provenance, "name", primary},
+		"provider":                {mod.ProviderConfigs["aws"].Provenance, "region", override},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if len(test.provenance.Definitions) != 2 {
+				t.Errorf("wrong number of definitions %d; want 2", len(test.provenance.Definitions))
+			}
+			if got := test.provenance.AttributeSources[test.attr].Filename; got != test.want {
+				t.Errorf("wrong source for %s: %s; want %s", test.attr, got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadModuleLegacyOverride(t *testing.T) {
+	mod, diags := LoadModule("testdata/legacy-override")
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %s", diags.Error())
+	}
+
+	v := mod.Variables["region"]
+	if v.Description != "The region, overridden" || v.Default != "us-east-1" || v.Required {
+		t.Errorf("wrong merged variable %#v", v)
+	}
+
+	o := mod.Outputs["region"]
+	if !o.Sensitive || o.Description != "The region in use" {
+		t.Errorf("wrong merged output %#v", o)
+	}
+
+	mc := mod.ModuleCalls["network"]
+	if mc.Source != "hashicorp/network/aws" || mc.Version != "2.0.0" {
+		t.Errorf("wrong merged module call %#v", mc)
+	}
+
+	if diff := cmp.Diff([]string{"~> 5.0"}, mod.RequiredProviders["aws"].VersionConstraints); diff != "" {
+		t.Errorf("wrong provider version constraints\n%s", diff)
+	}
+}
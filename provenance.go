@@ -0,0 +1,71 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Provenance records which blocks and arguments in the configuration files
+// contributed to an object that override files can change.
+//
+// It's embedded into the types for those objects, and is populated only for
+// modules loaded from configuration written for Terraform v0.12 or later.
+type Provenance struct {
+	// Definitions are the positions of the blocks that define the object,
+	// starting with its declaration in a primary file and followed by the
+	// blocks from override files that were merged into it, in the order
+	// they were merged.
+	Definitions []SourcePos `json:"definitions,omitempty"`
+
+	// AttributeSources are the positions of the arguments that supplied
+	// the final values of the object's arguments, by argument name,
+	// including meta-arguments like count and description.
+	AttributeSources map[string]SourcePos `json:"attribute_sources,omitempty"`
+}
+
+// newProvenance returns the provenance for an object defined by the block
+// with the given definition range, which sets the given arguments. attrs are
+// the arguments that are decoded as Attributes, if any.
+func newProvenance(defRange hcl.Range, args hcl.Attributes, attrs Attributes, opts *decodeOptions) Provenance {
+	p := Provenance{
+		Definitions: []SourcePos{sourcePosHCL(defRange, opts)},
+	}
+	for name, attr := range args {
+		p.setSource(name, attr.Range, opts)
+	}
+	for name, attr := range attrs {
+		p.setSource(name, attr.Range, opts)
+	}
+	return p
+}
+
+// attributePos returns the position of the argument with the given name that
+// supplied its final value, or the given fallback position if the argument's
+// source wasn't recorded.
+func (p *Provenance) attributePos(name string, fallback SourcePos) SourcePos {
+	if pos, ok := p.AttributeSources[name]; ok {
+		return pos
+	}
+	return fallback
+}
+
+func (p *Provenance) setSource(name string, rng hcl.Range, opts *decodeOptions) {
+	if p.AttributeSources == nil {
+		p.AttributeSources = make(map[string]SourcePos)
+	}
+	p.AttributeSources[name] = sourcePosHCL(rng, opts)
+}
+
+// merge records that the definition with the given provenance was merged
+// into the receiver.
+func (p *Provenance) merge(o Provenance) {
+	p.Definitions = append(p.Definitions, o.Definitions...)
+	for name, pos := range o.AttributeSources {
+		if p.AttributeSources == nil {
+			p.AttributeSources = make(map[string]SourcePos)
+		}
+		p.AttributeSources[name] = pos
+	}
+}
@@ -19,13 +19,22 @@ type ProviderRef struct {
 	Alias string `json:"alias,omitempty"` // Empty if the default provider configuration is referenced
 }
 
+// String returns the provider reference in the form used in configuration,
+// like "aws" or "aws.west".
+func (r ProviderRef) String() string {
+	if r.Alias == "" {
+		return r.Name
+	}
+	return r.Name + "." + r.Alias
+}
+
 type ProviderRequirement struct {
 	Source               string        `json:"source,omitempty"`
 	VersionConstraints   []string      `json:"version_constraints,omitempty"`
 	ConfigurationAliases []ProviderRef `json:"aliases,omitempty"`
 }
 
-func decodeRequiredProvidersBlock(block *hcl.Block) (map[string]*ProviderRequirement, hcl.Diagnostics) {
+func decodeRequiredProvidersBlock(block *hcl.Block, opts *decodeOptions) (map[string]*ProviderRequirement, hcl.Diagnostics) {
 	attrs, diags := block.Body.JustAttributes()
 	reqs := make(map[string]*ProviderRequirement)
 	for name, attr := range attrs {
@@ -44,12 +53,12 @@ func decodeRequiredProvidersBlock(block *hcl.Block) (map[string]*ProviderRequire
 
 		kvs, mapDiags := hcl.ExprMap(attr.Expr)
 		if mapDiags.HasErrors() {
-			diags = append(diags, &hcl.Diagnostic{
+			diags = append(diags, opts.diagnostic("invalid-required-providers", &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Invalid required_providers object",
 				Detail:   "Required providers entries must be strings or objects.",
 				Subject:  attr.Expr.Range().Ptr(),
-			})
+			}))
 			continue
 		}
 
@@ -63,12 +72,12 @@ func decodeRequiredProvidersBlock(block *hcl.Block) (map[string]*ProviderRequire
 			}
 
 			if key.Type() != cty.String {
-				diags = append(diags, &hcl.Diagnostic{
+				diags = append(diags, opts.diagnostic("invalid-provider-requirement", &hcl.Diagnostic{
 					Severity: hcl.DiagError,
 					Summary:  "Invalid Attribute",
 					Detail:   fmt.Sprintf("Invalid attribute value for provider requirement: %#v", key),
 					Subject:  kv.Key.Range().Ptr(),
-				})
+				}))
 				continue
 			}
 
@@ -76,12 +85,12 @@ func decodeRequiredProvidersBlock(block *hcl.Block) (map[string]*ProviderRequire
 			case "version":
 				version, valDiags := kv.Value.Value(nil)
 				if valDiags.HasErrors() || !version.Type().Equals(cty.String) {
-					diags = append(diags, &hcl.Diagnostic{
+					diags = append(diags, opts.diagnostic("invalid-provider-version-type", &hcl.Diagnostic{
 						Severity: hcl.DiagError,
 						Summary:  "Unsuitable value type",
 						Detail:   "Unsuitable value: string required",
 						Subject:  attr.Expr.Range().Ptr(),
-					})
+					}))
 					continue
 				}
 				if !version.IsNull() {
@@ -91,12 +100,12 @@ func decodeRequiredProvidersBlock(block *hcl.Block) (map[string]*ProviderRequire
 			case "source":
 				source, valDiags := kv.Value.Value(nil)
 				if valDiags.HasErrors() || !source.Type().Equals(cty.String) {
-					diags = append(diags, &hcl.Diagnostic{
+					diags = append(diags, opts.diagnostic("invalid-provider-source-type", &hcl.Diagnostic{
 						Severity: hcl.DiagError,
 						Summary:  "Unsuitable value type",
 						Detail:   "Unsuitable value: string required",
 						Subject:  attr.Expr.Range().Ptr(),
-					})
+					}))
 					continue
 				}
 
@@ -104,7 +113,7 @@ func decodeRequiredProvidersBlock(block *hcl.Block) (map[string]*ProviderRequire
 					pr.Source = source.AsString()
 				}
 			case "configuration_aliases":
-				aliases, valDiags := decodeConfigurationAliases(name, kv.Value)
+				aliases, valDiags := decodeConfigurationAliases(name, kv.Value, opts)
 				if valDiags.HasErrors() {
 					diags = append(diags, valDiags...)
 					continue
@@ -119,7 +128,7 @@ func decodeRequiredProvidersBlock(block *hcl.Block) (map[string]*ProviderRequire
 	return reqs, diags
 }
 
-func decodeConfigurationAliases(localName string, value hcl.Expression) ([]ProviderRef, hcl.Diagnostics) {
+func decodeConfigurationAliases(localName string, value hcl.Expression, opts *decodeOptions) ([]ProviderRef, hcl.Diagnostics) {
 	aliases := make([]ProviderRef, 0)
 	var diags hcl.Diagnostics
 
@@ -136,24 +145,24 @@ func decodeConfigurationAliases(localName string, value hcl.Expression) ([]Provi
 			continue
 		}
 
-		ref, cfgDiags := parseProviderRef(traversal)
+		ref, cfgDiags := parseProviderRef(traversal, opts)
 		if cfgDiags.HasErrors() {
-			diags = append(diags, &hcl.Diagnostic{
+			diags = append(diags, opts.diagnostic("invalid-configuration-aliases", &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Invalid configuration_aliases value",
 				Detail:   `Configuration aliases can only contain references to local provider configuration names in the format of provider.alias`,
 				Subject:  value.Range().Ptr(),
-			})
+			}))
 			continue
 		}
 
 		if ref.Name != localName {
-			diags = append(diags, &hcl.Diagnostic{
+			diags = append(diags, opts.diagnostic("invalid-configuration-aliases", &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Invalid configuration_aliases value",
 				Detail:   fmt.Sprintf(`Configuration aliases must be prefixed with the provider name. Expected %q, but found %q.`, localName, ref.Name),
 				Subject:  value.Range().Ptr(),
-			})
+			}))
 			continue
 		}
 
@@ -163,7 +172,54 @@ func decodeConfigurationAliases(localName string, value hcl.Expression) ([]Provi
 	return aliases, diags
 }
 
-func parseProviderRef(traversal hcl.Traversal) (ProviderRef, hcl.Diagnostics) {
+// decodeModuleCallProviders decodes the "providers" argument of a module call,
+// which maps provider configurations in the child module to provider
+// configurations in the calling module.
+func decodeModuleCallProviders(value hcl.Expression, opts *decodeOptions) (map[ProviderRef]ProviderRef, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	kvs, mapDiags := hcl.ExprMap(value)
+	if mapDiags.HasErrors() {
+		diags = append(diags, opts.diagnostic("invalid-providers-argument", &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid providers argument",
+			Detail:   "The providers argument must be a map from provider configuration names in the child module to provider configuration references in the calling module.",
+			Subject:  value.Range().Ptr(),
+		}))
+		return nil, diags
+	}
+
+	providers := make(map[ProviderRef]ProviderRef, len(kvs))
+	for _, kv := range kvs {
+		childTraversal, travDiags := hcl.AbsTraversalForExpr(kv.Key)
+		diags = append(diags, travDiags...)
+		if travDiags.HasErrors() {
+			continue
+		}
+		child, refDiags := parseProviderRef(childTraversal, opts)
+		diags = append(diags, refDiags...)
+		if refDiags.HasErrors() {
+			continue
+		}
+
+		parentTraversal, travDiags := hcl.AbsTraversalForExpr(kv.Value)
+		diags = append(diags, travDiags...)
+		if travDiags.HasErrors() {
+			continue
+		}
+		parent, refDiags := parseProviderRef(parentTraversal, opts)
+		diags = append(diags, refDiags...)
+		if refDiags.HasErrors() {
+			continue
+		}
+
+		providers[child] = parent
+	}
+
+	return providers, diags
+}
+
+func parseProviderRef(traversal hcl.Traversal, opts *decodeOptions) (ProviderRef, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 	ret := ProviderRef{
 		Name: traversal.RootName(),
@@ -177,24 +233,25 @@ func parseProviderRef(traversal hcl.Traversal) (ProviderRef, hcl.Diagnostics) {
 	aliasStep := traversal[1]
 	switch ts := aliasStep.(type) {
 	case hcl.TraverseAttr:
+		// Don't return yet: an address with steps after the alias, like
+		// aws.west.foo, is invalid even though its alias is well-formed.
 		ret.Alias = ts.Name
-		return ret, diags
 	default:
-		diags = diags.Append(&hcl.Diagnostic{
+		diags = diags.Append(opts.diagnostic("invalid-provider-config-address", &hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Invalid provider configuration address",
 			Detail:   "The provider type name must either stand alone or be followed by an alias name separated with a dot.",
 			Subject:  aliasStep.SourceRange().Ptr(),
-		})
+		}))
 	}
 
 	if len(traversal) > 2 {
-		diags = diags.Append(&hcl.Diagnostic{
+		diags = diags.Append(opts.diagnostic("invalid-provider-config-address", &hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Invalid provider configuration address",
 			Detail:   "Extraneous extra operators after provider configuration address.",
 			Subject:  traversal[2:].SourceRange().Ptr(),
-		})
+		}))
 	}
 
 	return ret, diags
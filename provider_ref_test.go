@@ -0,0 +1,62 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestDecodeModuleCallProviders(t *testing.T) {
+	tests := map[string]struct {
+		want     map[ProviderRef]ProviderRef
+		wantDiag string
+	}{
+		`{ aws = aws.west }`: {
+			want: map[ProviderRef]ProviderRef{
+				{Name: "aws"}: {Name: "aws", Alias: "west"},
+			},
+		},
+		`{ aws.east = aws, google = google }`: {
+			want: map[ProviderRef]ProviderRef{
+				{Name: "aws", Alias: "east"}: {Name: "aws"},
+				{Name: "google"}:             {Name: "google"},
+			},
+		},
+		`{ aws = aws.west.foo }`: {
+			want:     map[ProviderRef]ProviderRef{},
+			wantDiag: "Extraneous extra operators after provider configuration address.",
+		},
+		`{ aws.east.foo = aws }`: {
+			want:     map[ProviderRef]ProviderRef{},
+			wantDiag: "Extraneous extra operators after provider configuration address.",
+		},
+		`{ aws = aws[0] }`: {
+			want:     map[ProviderRef]ProviderRef{},
+			wantDiag: "The provider type name must either stand alone or be followed by an alias name separated with a dot.",
+		},
+	}
+
+	for src, test := range tests {
+		t.Run(src, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", diags.Error())
+			}
+			got, diags := decodeModuleCallProviders(expr, newDecodeOptions(LoadOptions{}))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+			switch {
+			case test.wantDiag == "" && len(diags) != 0:
+				t.Errorf("unexpected diagnostics: %s", diags.Error())
+			case test.wantDiag != "" && (len(diags) != 1 || diags[0].Detail != test.wantDiag):
+				t.Errorf("wrong diagnostics %s; want one with detail %q", diags.Error(), test.wantDiag)
+			}
+		})
+	}
+}
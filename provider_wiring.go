@@ -0,0 +1,175 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateProviderWiring checks that the provider configurations passed to
+// each child module in the tree are consistent with what the child module
+// expects, returning diagnostics describing any problems.
+//
+// In particular, it checks that:
+//   - each configuration alias declared in a child module's
+//     required_providers block is passed in by its module call,
+//   - each provider configuration passed in a module call's providers
+//     argument is defined in the calling module, and
+//   - the provider source addresses agree between the calling module and
+//     the child module for each configuration passed, whether explicitly or
+//     by implicit inheritance.
+//
+// Each diagnostic refers to the position of the offending module call.
+func (t *ModuleTree) ValidateProviderWiring() Diagnostics {
+	var diags Diagnostics
+	for _, path := range sortedKeys(t.Modules) {
+		node := t.Modules[path]
+		if node.Parent == nil {
+			continue
+		}
+		diags = append(diags, validateModuleCallProviders(node.Call, node.Parent.Module, node.Module)...)
+	}
+	return diags
+}
+
+func validateModuleCallProviders(call *ModuleCall, parent, child *Module) Diagnostics {
+	var diags Diagnostics
+	pos := call.Pos
+
+	for _, name := range sortedKeys(child.RequiredProviders) {
+		for _, alias := range child.RequiredProviders[name].ConfigurationAliases {
+			if _, passed := call.Providers[alias]; passed {
+				continue
+			}
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "missing-provider-config",
+				Summary:  "Missing required provider configuration",
+				Detail:   fmt.Sprintf("The child module requires an additional configuration for provider %s, with the local name %q. Refer to the module's documentation to understand the intended purpose of this additional provider configuration, and then add an entry for %s in the \"providers\" meta-argument of module call %q.", name, alias.String(), alias.String(), call.Name),
+				Pos:      &pos,
+			})
+		}
+	}
+
+	if len(call.Providers) == 0 {
+		// Without an explicit providers argument, the child module inherits
+		// the default configuration of each provider by its local name.
+		for _, name := range sortedKeys(child.RequiredProviders) {
+			if _, exists := parent.RequiredProviders[name]; !exists {
+				continue
+			}
+			diags = append(diags, validateProviderSources(call, ProviderRef{Name: name}, parent, ProviderRef{Name: name}, child)...)
+		}
+		return diags
+	}
+
+	for _, childRef := range sortedProviderRefs(call.Providers) {
+		parentRef := call.Providers[childRef]
+
+		if !moduleHasProviderConfig(parent, parentRef) {
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "undefined-provider-config",
+				Summary:  "Reference to undefined provider configuration",
+				Detail:   fmt.Sprintf("Module call %q passes provider configuration %s, which is not declared in the calling module.", call.Name, parentRef.String()),
+				Pos:      &pos,
+			})
+			continue
+		}
+
+		if _, declared := child.RequiredProviders[childRef.Name]; !declared {
+			diags = append(diags, Diagnostic{
+				Severity: DiagWarning,
+				Code:     "undefined-provider",
+				Summary:  "Reference to undefined provider",
+				Detail:   fmt.Sprintf("Module call %q passes a configuration for provider %q, but the child module does not declare a provider with that local name.", call.Name, childRef.Name),
+				Pos:      &pos,
+			})
+			continue
+		}
+
+		diags = append(diags, validateProviderSources(call, parentRef, parent, childRef, child)...)
+	}
+
+	return diags
+}
+
+// validateProviderSources checks that the given provider configurations in
+// the parent and child modules are for the same provider.
+func validateProviderSources(call *ModuleCall, parentRef ProviderRef, parent *Module, childRef ProviderRef, child *Module) Diagnostics {
+	parentSource := moduleProviderSource(parent, parentRef.Name)
+	childSource := moduleProviderSource(child, childRef.Name)
+	if parentSource == childSource {
+		return nil
+	}
+	pos := call.Pos
+	return Diagnostics{
+		{
+			Severity: DiagError,
+			Code:     "provider-type-mismatch",
+			Summary:  "Provider type mismatch",
+			Detail:   fmt.Sprintf("Module call %q passes provider configuration %s for provider %s to the child module's %s, which is for provider %s. The calling module and the child module must agree on the provider source address.", call.Name, parentRef.String(), parentSource, childRef.String(), childSource),
+			Pos:      &pos,
+		},
+	}
+}
+
+// moduleHasProviderConfig returns true if the given provider configuration
+// may be referred to from within the given module.
+func moduleHasProviderConfig(mod *Module, ref ProviderRef) bool {
+	if ref.Alias == "" {
+		// Default provider configurations exist implicitly, even if they
+		// have no provider block.
+		return true
+	}
+	if _, exists := mod.ProviderConfigs[ref.String()]; exists {
+		return true
+	}
+	// The configuration may also be passed in from the calling module.
+	if req, exists := mod.RequiredProviders[ref.Name]; exists {
+		for _, alias := range req.ConfigurationAliases {
+			if alias == ref {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// moduleProviderSource returns the fully-qualified source address of the
+// provider with the given local name in the given module.
+func moduleProviderSource(mod *Module, localName string) string {
+	var source string
+	if req, exists := mod.RequiredProviders[localName]; exists {
+		source = req.Source
+	}
+	return normalizeProviderSource(localName, source)
+}
+
+// normalizeProviderSource returns the fully-qualified form of the given
+// provider source address, like "registry.terraform.io/hashicorp/aws".
+// Providers without a source address are assumed to be in the "hashicorp"
+// namespace of the public registry, as in Terraform itself.
+func normalizeProviderSource(localName, source string) string {
+	if source == "" {
+		source = "hashicorp/" + localName
+	}
+	if strings.Count(source, "/") == 1 {
+		source = "registry.terraform.io/" + source
+	}
+	return strings.ToLower(source)
+}
+
+func sortedProviderRefs(m map[ProviderRef]ProviderRef) []ProviderRef {
+	keys := make(map[string]ProviderRef, len(m))
+	for ref := range m {
+		keys[ref.String()] = ref
+	}
+	ret := make([]ProviderRef, 0, len(keys))
+	for _, k := range sortedKeys(keys) {
+		ret = append(ret, keys[k])
+	}
+	return ret
+}
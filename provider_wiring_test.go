@@ -0,0 +1,39 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleTreeValidateProviderWiring(t *testing.T) {
+	tree, diags := LoadModuleTree("testdata/provider-wiring")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors loading module tree: %s", diags.Error())
+	}
+
+	type diagSummary struct {
+		Severity DiagSeverity
+		Summary  string
+		Line     int
+	}
+	var got []diagSummary
+	for _, diag := range tree.ValidateProviderWiring() {
+		if diag.Pos.Filename != "testdata/provider-wiring/main.tf" {
+			t.Errorf("diagnostic %q refers to wrong file %s", diag.Summary, diag.Pos.Filename)
+		}
+		got = append(got, diagSummary{diag.Severity, diag.Summary, diag.Pos.Line})
+	}
+	want := []diagSummary{
+		{DiagError, "Provider type mismatch", 51},
+		{DiagError, "Missing required provider configuration", 25},
+		{DiagError, "Reference to undefined provider configuration", 33},
+		{DiagError, "Provider type mismatch", 42},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+}
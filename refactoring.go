@@ -0,0 +1,309 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+)
+
+// Moved represents a "moved" block, which records that an object previously
+// known by one address is now known by another.
+type Moved struct {
+	From *Address `json:"from"`
+	To   *Address `json:"to"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// Removed represents a "removed" block, which records that an object has
+// been removed from the configuration.
+type Removed struct {
+	From *Address `json:"from"`
+
+	// Destroy is false if the removed block's lifecycle block sets destroy
+	// to false, meaning that the object should be forgotten rather than
+	// destroyed.
+	Destroy bool `json:"destroy"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// Import represents an "import" block, which records that an existing
+// infrastructure object should be imported to a resource address.
+type Import struct {
+	// To is the resource instance to import to. If the block uses for_each
+	// then the instance key is usually an expression, recorded in the
+	// KeyExpr field of the address.
+	To *Address `json:"to"`
+
+	ID       *Expression  `json:"id,omitempty"`
+	ForEach  *Expression  `json:"for_each,omitempty"`
+	Provider *ProviderRef `json:"provider,omitempty"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+func decodeMovedBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*Moved, hcl.Diagnostics) {
+	content, diags := block.Body.Content(movedSchema)
+
+	m := &Moved{
+		Pos: sourcePosHCL(block.DefRange, opts),
+	}
+
+	if attr, defined := content.Attributes["from"]; defined {
+		addr, addrDiags := decodeAddress(attr.Expr, file, false, opts)
+		diags = append(diags, addrDiags...)
+		m.From = addr
+	}
+
+	if attr, defined := content.Attributes["to"]; defined {
+		addr, addrDiags := decodeAddress(attr.Expr, file, false, opts)
+		diags = append(diags, addrDiags...)
+		m.To = addr
+	}
+
+	return m, diags
+}
+
+func decodeRemovedBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*Removed, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(removedSchema)
+
+	r := &Removed{
+		Destroy: true,
+		Pos:     sourcePosHCL(block.DefRange, opts),
+	}
+
+	if attr, defined := content.Attributes["from"]; defined {
+		addr, addrDiags := decodeAddress(attr.Expr, file, false, opts)
+		diags = append(diags, addrDiags...)
+		r.From = addr
+	}
+
+	for _, block := range content.Blocks {
+		lcContent, lcDiags := block.Body.Content(removedLifecycleSchema)
+		diags = append(diags, lcDiags...)
+		if attr, defined := lcContent.Attributes["destroy"]; defined {
+			valDiags := gohcl.DecodeExpression(attr.Expr, nil, &r.Destroy)
+			diags = append(diags, valDiags...)
+		}
+	}
+
+	return r, diags
+}
+
+func decodeImportBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*Import, hcl.Diagnostics) {
+	content, diags := block.Body.Content(importSchema)
+
+	imp := &Import{
+		Pos: sourcePosHCL(block.DefRange, opts),
+	}
+
+	if attr, defined := content.Attributes["for_each"]; defined {
+		imp.ForEach = newExpression(attr.Expr, file, opts)
+	}
+
+	if attr, defined := content.Attributes["to"]; defined {
+		addr, addrDiags := decodeAddress(attr.Expr, file, imp.ForEach != nil, opts)
+		diags = append(diags, addrDiags...)
+		imp.To = addr
+	}
+
+	if attr, defined := content.Attributes["id"]; defined {
+		imp.ID = newExpression(attr.Expr, file, opts)
+	}
+
+	if attr, defined := content.Attributes["provider"]; defined {
+		traversal, travDiags := hcl.AbsTraversalForExpr(attr.Expr)
+		if travDiags.HasErrors() {
+			diags = append(diags, opts.diagnostic("invalid-provider-reference", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid provider reference",
+				Detail:   "Provider argument requires a provider name followed by an optional alias, like aws.foo.",
+				Subject:  attr.Expr.Range().Ptr(),
+			}))
+		} else {
+			ref, refDiags := parseProviderRef(traversal, opts)
+			diags = append(diags, refDiags...)
+			if !refDiags.HasErrors() {
+				imp.Provider = &ref
+			}
+		}
+	}
+
+	return imp, diags
+}
+
+// validateRefactoring checks that the module's moved, removed and import
+// blocks are consistent with each other and with the objects declared in
+// the module.
+//
+// Only the first step of an address that refers into a child module can be
+// checked, because the child module's own declarations are not available.
+func (m *Module) validateRefactoring() Diagnostics {
+	var diags Diagnostics
+
+	// A moved block's target may itself be moved again by a later
+	// refactoring, in which case only the end of the chain must be declared.
+	movedFrom := make(map[string]bool)
+	for _, mv := range m.Moved {
+		if mv.From != nil {
+			movedFrom[mv.From.String()] = true
+		}
+	}
+
+	for _, mv := range m.Moved {
+		if mv.From == nil || mv.To == nil {
+			continue
+		}
+		pos := mv.Pos
+		switch {
+		case mv.From.IsModule() != mv.To.IsModule():
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "invalid-moved",
+				Summary:  "Invalid moved statement",
+				Detail:   fmt.Sprintf("Can't move %s to %s: a module can only be moved to another module address, and a resource to another resource address.", mv.From, mv.To),
+				Pos:      &pos,
+			})
+		case !mv.From.IsModule() && (mv.From.Resource.Mode == DataResourceMode || mv.To.Resource.Mode == DataResourceMode):
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "invalid-moved",
+				Summary:  "Invalid moved statement",
+				Detail:   fmt.Sprintf("Can't move %s to %s: data resources can't be moved.", mv.From, mv.To),
+				Pos:      &pos,
+			})
+		case !movedFrom[mv.To.String()] && !m.declares(mv.To):
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "undeclared-moved-target",
+				Summary:  "Moved target is not declared",
+				Detail:   fmt.Sprintf("The moved block refers to %s as the new address, but this module does not declare %s.", mv.To, mv.To.configKey()),
+				Pos:      &pos,
+			})
+		}
+	}
+	diags = append(diags, m.movedCycleDiagnostics()...)
+
+	for _, r := range m.Removed {
+		// A removed block can refer to an object within a child module
+		// that is itself still declared, so we can only check objects that
+		// would be declared directly in this module.
+		if r.From == nil || len(r.From.ModulePath) > 1 || (len(r.From.ModulePath) == 1 && !r.From.IsModule()) {
+			continue
+		}
+		if m.declares(r.From) {
+			pos := r.Pos
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "removed-object-declared",
+				Summary:  "Removed object still declared",
+				Detail:   fmt.Sprintf("The removed block refers to %s, but this module still declares %s. Remove the declaration, or remove the removed block.", r.From, r.From.configKey()),
+				Pos:      &pos,
+			})
+		}
+	}
+
+	for _, imp := range m.Imports {
+		if imp.To == nil {
+			continue
+		}
+		pos := imp.Pos
+		switch {
+		case imp.To.IsModule() || imp.To.Resource.Mode != ManagedResourceMode:
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "invalid-import-address",
+				Summary:  "Invalid import address",
+				Detail:   fmt.Sprintf("Can't import to %s: only managed resources can be imported.", imp.To),
+				Pos:      &pos,
+			})
+		case !m.declares(imp.To):
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "undeclared-import-target",
+				Summary:  "Import target is not declared",
+				Detail:   fmt.Sprintf("The import block refers to %s, but this module does not declare %s.", imp.To, imp.To.configKey()),
+				Pos:      &pos,
+			})
+		}
+	}
+
+	return diags
+}
+
+// declares returns true if the module declares the resource or module call
+// that the given address refers to.
+func (m *Module) declares(addr *Address) bool {
+	if len(addr.ModulePath) > 0 {
+		_, exists := m.ModuleCalls[addr.ModulePath[0].Name]
+		return exists
+	}
+	key := addr.configKey()
+	if addr.Resource.Mode == DataResourceMode {
+		_, exists := m.DataResources[key]
+		return exists
+	}
+	_, exists := m.ManagedResources[key]
+	return exists
+}
+
+// movedCycleDiagnostics returns an error for each chain of moved blocks
+// that leads back to its own starting address, reported at the first moved
+// block of the chain.
+func (m *Module) movedCycleDiagnostics() Diagnostics {
+	var diags Diagnostics
+
+	next := make(map[string]int)
+	for i, mv := range m.Moved {
+		if mv.From == nil || mv.To == nil {
+			continue
+		}
+		if _, exists := next[mv.From.String()]; !exists {
+			next[mv.From.String()] = i
+		}
+	}
+
+	for i, mv := range m.Moved {
+		if mv.From == nil || mv.To == nil {
+			continue
+		}
+		start := mv.From.String()
+		chain := []string{start}
+		first := i
+		seen := map[string]bool{start: true}
+		addr := mv.To.String()
+		for !seen[addr] {
+			seen[addr] = true
+			chain = append(chain, addr)
+			j, exists := next[addr]
+			if !exists {
+				break
+			}
+			if j < first {
+				first = j
+			}
+			addr = m.Moved[j].To.String()
+		}
+		// Report each cycle only once, from the earliest of its moved
+		// blocks, and only if the chain returned to where it started.
+		if addr != start || first != i {
+			continue
+		}
+		pos := mv.Pos
+		diags = append(diags, Diagnostic{
+			Severity: DiagError,
+			Code:     "moved-cycle",
+			Summary:  "Cyclic moved statements",
+			Detail:   fmt.Sprintf("The moved blocks form a cycle: %s -> %s.", strings.Join(chain, " -> "), start),
+			Pos:      &pos,
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestModuleRefactoring(t *testing.T) {
+	mod, diags := LoadModule("testdata/refactoring")
+
+	type diagSummary struct {
+		Severity DiagSeverity
+		Summary  string
+		Line     int
+	}
+	var got []diagSummary
+	for _, diag := range diags {
+		got = append(got, diagSummary{diag.Severity, diag.Summary, diag.Pos.Line})
+	}
+	want := []diagSummary{
+		{DiagError, "Moved target is not declared", 28},
+		{DiagError, "Cyclic moved statements", 33},
+		{DiagError, "Removed object still declared", 55},
+		{DiagError, "Import target is not declared", 71},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+
+	var gotAddrs []string
+	for _, mv := range mod.Moved {
+		gotAddrs = append(gotAddrs, mv.From.String()+" -> "+mv.To.String())
+	}
+	for _, r := range mod.Removed {
+		gotAddrs = append(gotAddrs, "removed "+r.From.String())
+	}
+	for _, imp := range mod.Imports {
+		gotAddrs = append(gotAddrs, "import "+imp.To.String())
+	}
+	wantAddrs := []string{
+		"aws_instance.app -> aws_instance.web[0]",
+		`module.vpc["east"] -> module.network`,
+		"module.old.aws_subnet.public -> module.network.aws_subnet.public[1]",
+		"aws_instance.old -> aws_instance.missing",
+		"aws_instance.loop_a -> aws_instance.loop_b",
+		"aws_instance.loop_b -> aws_instance.loop_a",
+		"removed aws_instance.legacy",
+		"removed module.network.aws_route.default",
+		"removed aws_instance.web",
+		"import aws_instance.web[1]",
+		"import aws_s3_bucket.logs[each.key]",
+		"import aws_instance.nope",
+	}
+	if diff := cmp.Diff(wantAddrs, gotAddrs); diff != "" {
+		t.Errorf("wrong addresses\n%s", diff)
+	}
+
+	if mod.Removed[0].Destroy {
+		t.Errorf("removed block with destroy = false has Destroy set")
+	}
+	if !mod.Removed[1].Destroy {
+		t.Errorf("removed block without lifecycle has Destroy unset")
+	}
+}
+
+func TestDecodeAddressErrors(t *testing.T) {
+	tests := map[string]string{
+		"attribute":    "aws_instance.web.id",
+		"missing name": "aws_instance",
+		"module name":  "module",
+		"fraction key": "aws_instance.web[1.5]",
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(src), "", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", diags.Error())
+			}
+			_, diags = decodeAddress(expr, nil, false, newDecodeOptions(LoadOptions{}))
+			if !diags.HasErrors() || diags[0].Summary != "Invalid address" {
+				t.Errorf("wrong diagnostics for %q: %s", src, diags.Error())
+			}
+		})
+	}
+}
+
+func TestDecodeImportBlockProvider(t *testing.T) {
+	tests := map[string]string{
+		"aws.west":      "",
+		"aws[0]":        "Invalid provider configuration address",
+		"aws.west.east": "Invalid provider configuration address",
+		`"aws"`:         "Invalid provider reference",
+	}
+	for src, wantSummary := range tests {
+		t.Run(src, func(t *testing.T) {
+			config := "import {\n  to = aws_instance.web\n  id = \"i-123\"\n  provider = " + src + "\n}\n"
+			file, diags := hclsyntax.ParseConfig([]byte(config), "test.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", diags.Error())
+			}
+			content, _ := file.Body.Content(rootSchema)
+			imp, diags := decodeImportBlock(content.Blocks[0], file, newDecodeOptions(LoadOptions{}))
+			if wantSummary == "" {
+				if diags.HasErrors() {
+					t.Fatalf("unexpected errors: %s", diags.Error())
+				}
+				if got := imp.Provider.String(); got != src {
+					t.Errorf("wrong provider %s", got)
+				}
+				return
+			}
+			if !diags.HasErrors() || diags[0].Summary != wantSummary {
+				t.Errorf("wrong diagnostics: %s", diags.Error())
+			}
+			if imp.Provider != nil {
+				t.Errorf("unexpected provider %s", imp.Provider.String())
+			}
+		})
+	}
+}
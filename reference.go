@@ -0,0 +1,208 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Reference describes a reference from an expression to some other object
+// in the module, such as an input variable or a resource.
+type Reference struct {
+	Kind ReferenceKind `json:"kind"`
+
+	// Subject is the address of the referenced object, like "var.foo",
+	// "local.bar", "module.baz.out" or "aws_instance.example".
+	Subject string `json:"subject"`
+
+	// Traversal is the full traversal that made the reference, which may
+	// include additional steps after the subject, such as the attribute
+	// name in "aws_instance.example.id".
+	Traversal hcl.Traversal `json:"-"`
+
+	// Range is the source range of Traversal.
+	Range hcl.Range `json:"-"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// ReferenceKind represents the kind of object a Reference refers to.
+type ReferenceKind rune
+
+const InvalidReferenceKind ReferenceKind = 0
+const VariableReferenceKind ReferenceKind = 'V'
+const LocalReferenceKind ReferenceKind = 'L'
+const ModuleCallReferenceKind ReferenceKind = 'M'
+const DataResourceReferenceKind ReferenceKind = 'D'
+const ManagedResourceReferenceKind ReferenceKind = 'R'
+const CountReferenceKind ReferenceKind = 'C'
+const EachReferenceKind ReferenceKind = 'E'
+const PathReferenceKind ReferenceKind = 'P'
+const TerraformReferenceKind ReferenceKind = 'T'
+const SelfReferenceKind ReferenceKind = 'S'
+
+// OtherReferenceKind is for references to names that are not objects in the
+// module, such as the iterator of a dynamic block, like "ingress.value".
+const OtherReferenceKind ReferenceKind = 'O'
+
+func (k ReferenceKind) String() string {
+	switch k {
+	case VariableReferenceKind:
+		return "variable"
+	case LocalReferenceKind:
+		return "local"
+	case ModuleCallReferenceKind:
+		return "module"
+	case DataResourceReferenceKind:
+		return "data"
+	case ManagedResourceReferenceKind:
+		return "resource"
+	case CountReferenceKind:
+		return "count"
+	case EachReferenceKind:
+		return "each"
+	case PathReferenceKind:
+		return "path"
+	case TerraformReferenceKind:
+		return "terraform"
+	case SelfReferenceKind:
+		return "self"
+	case OtherReferenceKind:
+		return "other"
+	default:
+		return ""
+	}
+}
+
+// MarshalJSON implements encoding/json.Marshaler.
+func (k ReferenceKind) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(k.String())), nil
+}
+
+// referencesForExpr returns the references made by the given expression,
+// in the order they appear in the source. Multiple references to the same
+// object are all included. iterators are the names of the iterators of any
+// enclosing dynamic blocks, which are not references to other objects.
+func referencesForExpr(expr hcl.Expression, iterators map[string]bool, opts *decodeOptions) []Reference {
+	if expr == nil {
+		return nil
+	}
+	var refs []Reference
+	for _, traversal := range expr.Variables() {
+		ref, ok := newReference(traversal, iterators, opts)
+		if !ok {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// newReference constructs a Reference from the given traversal, returning
+// false if the traversal does not refer to any object.
+func newReference(traversal hcl.Traversal, iterators map[string]bool, opts *decodeOptions) (Reference, bool) {
+	if len(traversal) == 0 {
+		return Reference{}, false
+	}
+
+	// Most objects are identified by the root name followed by a fixed
+	// number of attribute names, but that number varies by object type.
+	var kind ReferenceKind
+	steps := 2
+	switch traversal.RootName() {
+	case "var":
+		kind = VariableReferenceKind
+	case "local":
+		kind = LocalReferenceKind
+	case "module":
+		// We include the output name, if present, because callers often
+		// care about exactly which output of a module is used.
+		kind = ModuleCallReferenceKind
+		steps = 3
+	case "data":
+		kind = DataResourceReferenceKind
+		steps = 3
+	case "count":
+		kind = CountReferenceKind
+	case "each":
+		kind = EachReferenceKind
+	case "path":
+		kind = PathReferenceKind
+	case "terraform":
+		kind = TerraformReferenceKind
+	case "self":
+		kind = SelfReferenceKind
+		steps = 1
+	default:
+		// Resource types are always prefixed by the provider's local name
+		// and an underscore, like "aws_instance", which distinguishes them
+		// from most names that are only in scope within a particular block.
+		// The iterators of enclosing dynamic blocks can have any name, so
+		// we exclude those explicitly.
+		kind = OtherReferenceKind
+		if root := traversal.RootName(); strings.Contains(root, "_") && !iterators[root] {
+			kind = ManagedResourceReferenceKind
+		}
+	}
+
+	parts := []string{traversal.RootName()}
+	for _, step := range traversal[1:] {
+		if len(parts) == steps {
+			break
+		}
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		parts = append(parts, attr.Name)
+	}
+	if len(parts) < 2 && kind != SelfReferenceKind {
+		// Incomplete references, like "var" on its own, don't refer to
+		// any particular object.
+		return Reference{}, false
+	}
+
+	rng := traversal.SourceRange()
+	return Reference{
+		Kind:      kind,
+		Subject:   strings.Join(parts, "."),
+		Traversal: traversal,
+		Range:     rng,
+		Pos:       sourcePosHCL(rng, opts),
+	}, true
+}
+
+// decodeDependsOn decodes the references in a depends_on argument, which
+// must be a list of references to other objects.
+func decodeDependsOn(attr *hcl.Attribute, opts *decodeOptions) ([]Reference, hcl.Diagnostics) {
+	exprs, diags := hcl.ExprList(attr.Expr)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var refs []Reference
+	for _, expr := range exprs {
+		traversal, travDiags := hcl.AbsTraversalForExpr(expr)
+		var ref Reference
+		ok := !travDiags.HasErrors()
+		if ok {
+			ref, ok = newReference(traversal, nil, opts)
+			ok = ok && ref.Kind != OtherReferenceKind
+		}
+		if !ok {
+			diags = append(diags, opts.diagnostic("invalid-depends-on-reference", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid depends_on reference",
+				Detail:   "The depends_on argument must contain only references to other objects in the module, like aws_instance.example.",
+				Subject:  expr.Range().Ptr(),
+			}))
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs, diags
+}
@@ -0,0 +1,102 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestReferencesForExpr(t *testing.T) {
+	tests := map[string][]string{
+		`var.foo`:                {"variable var.foo"},
+		`local.bar[0]`:           {"local local.bar"},
+		`module.net.vpc_id`:      {"module module.net.vpc_id"},
+		`module.net`:             {"module module.net"},
+		`data.aws_ami.ubuntu.id`: {"data data.aws_ami.ubuntu"},
+		`aws_vpc.main.id`:        {"resource aws_vpc.main"},
+		`aws_instance.web[count.index].private_ip`:  {"resource aws_instance.web", "count count.index"},
+		`"${each.key}-${path.module}"`:              {"each each.key", "path path.module"},
+		`terraform.workspace`:                       {"terraform terraform.workspace"},
+		`self.private_ip`:                           {"self self"},
+		`[for s in var.subnets : s.id if s.public]`: {"variable var.subnets"},
+		`ingress.value.from_port`:                   {"other ingress.value"},
+		`var`:                                       nil,
+		`"literal"`:                                 nil,
+	}
+
+	for src, want := range tests {
+		t.Run(src, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Error())
+			}
+			var got []string
+			for _, ref := range referencesForExpr(expr, nil, newDecodeOptions(LoadOptions{})) {
+				got = append(got, ref.Kind.String()+" "+ref.Subject)
+				if ref.Range.Filename != "test.tf" {
+					t.Errorf("reference %s has wrong range %s", ref.Subject, ref.Range)
+				}
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("wrong references\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeBodyDynamicIterators(t *testing.T) {
+	config := `
+dynamic "ingress_rule" {
+  for_each = aws_vpc.main.cidrs
+  content {
+    from_port = ingress_rule.value
+    cidr      = aws_vpc.main.cidr
+  }
+}
+
+dynamic "egress_rule" {
+  for_each = var.rules
+  iterator = rule_item
+  content {
+    to_port = rule_item.value
+  }
+}
+`
+	file, diags := hclsyntax.ParseConfig([]byte(config), "test.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected parse errors: %s", diags.Error())
+	}
+	_, blocks, diags := decodeBody(file.Body, file, nil, newDecodeOptions(LoadOptions{}))
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+
+	var got []string
+	var collect func(blocks []*Block)
+	collect = func(blocks []*Block) {
+		for _, block := range blocks {
+			for _, name := range sortedKeys(block.Attributes) {
+				for _, ref := range block.Attributes[name].References {
+					got = append(got, name+": "+ref.Kind.String()+" "+ref.Subject)
+				}
+			}
+			collect(block.Blocks)
+		}
+	}
+	collect(blocks)
+	want := []string{
+		"for_each: resource aws_vpc.main",
+		"cidr: resource aws_vpc.main",
+		"from_port: other ingress_rule.value",
+		"for_each: variable var.rules",
+		"to_port: other rule_item.value",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong references\n%s", diff)
+	}
+}
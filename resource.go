@@ -7,6 +7,10 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
 
 // Resource represents a single "resource" or "data" block within a module.
@@ -15,10 +19,19 @@ type Resource struct {
 	Type       string       `json:"type"`
 	Name       string       `json:"name"`
 	Attributes Attributes   `json:"attributes,omitempty"`
+	Blocks     []*Block     `json:"blocks,omitempty"`
 
 	Provider ProviderRef `json:"provider"`
 
+	// Count, ForEach and DependsOn are the meta-arguments of the same names,
+	// if set. Lifecycle is the resource's lifecycle block, if any.
+	Count     *Expression `json:"count,omitempty"`
+	ForEach   *Expression `json:"for_each,omitempty"`
+	DependsOn []Reference `json:"depends_on,omitempty"`
+	Lifecycle *Lifecycle  `json:"lifecycle,omitempty"`
+
 	Pos SourcePos `json:"pos"`
+	Provenance
 }
 
 // MapKey returns a string that can be used to uniquely identify the receiver
@@ -35,6 +48,117 @@ func (r *Resource) MapKey() string {
 	}
 }
 
+// decodeResourceBlock decodes a "resource" or "data" block from the given
+// file.
+func decodeResourceBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*Resource, hcl.Diagnostics) {
+	content, remaining, diags := block.Body.PartialContent(resourceSchema)
+
+	typeName := block.Labels[0]
+	name := block.Labels[1]
+
+	r := &Resource{
+		Type: typeName,
+		Name: name,
+		Pos:  sourcePosHCL(block.DefRange, opts),
+	}
+
+	switch block.Type {
+	case "resource":
+		r.Mode = ManagedResourceMode
+	case "data":
+		r.Mode = DataResourceMode
+	}
+
+	attrs, blocks, bodyDiags := decodeBody(remaining, file, nil, opts)
+	diags = append(diags, bodyDiags...)
+	r.Attributes = attrs
+	r.Blocks = blocks
+	r.Provenance = newProvenance(block.DefRange, content.Attributes, attrs, opts)
+
+	if attr, defined := content.Attributes["count"]; defined {
+		r.Count = newExpression(attr.Expr, file, opts)
+	}
+
+	if attr, defined := content.Attributes["for_each"]; defined {
+		r.ForEach = newExpression(attr.Expr, file, opts)
+	}
+
+	if attr, defined := content.Attributes["depends_on"]; defined {
+		refs, refsDiags := decodeDependsOn(attr, opts)
+		diags = append(diags, refsDiags...)
+		r.DependsOn = refs
+	}
+
+	var lifecycleRange hcl.Range
+	for _, block := range content.Blocks {
+		if r.Lifecycle != nil {
+			diags = append(diags, opts.diagnostic("duplicate-lifecycle", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate lifecycle block",
+				Detail:   fmt.Sprintf("This resource already has a lifecycle block at %s.", lifecycleRange),
+				Subject:  block.DefRange.Ptr(),
+			}))
+			continue
+		}
+		lc, lcDiags := decodeLifecycle(block, file, opts)
+		diags = append(diags, lcDiags...)
+		r.Lifecycle = lc
+		lifecycleRange = block.DefRange
+	}
+
+	if attr, defined := content.Attributes["provider"]; defined {
+		// New style here is to provide this as a naked traversal
+		// expression, but we also support quoted references for
+		// older configurations that predated this convention.
+		traversal, travDiags := hcl.AbsTraversalForExpr(attr.Expr)
+		if travDiags.HasErrors() {
+			traversal = nil // in case we got any partial results
+
+			// Fall back on trying to parse as a string
+			var travStr string
+			valDiags := gohcl.DecodeExpression(attr.Expr, nil, &travStr)
+			if !valDiags.HasErrors() {
+				var strDiags hcl.Diagnostics
+				traversal, strDiags = hclsyntax.ParseTraversalAbs([]byte(travStr), "", hcl.Pos{})
+				if strDiags.HasErrors() {
+					traversal = nil
+				}
+			}
+		}
+
+		// If we get out here with a nil traversal then we didn't
+		// succeed in processing the input.
+		if len(traversal) > 0 {
+			providerName := traversal.RootName()
+			alias := ""
+			if len(traversal) > 1 {
+				if getAttr, ok := traversal[1].(hcl.TraverseAttr); ok {
+					alias = getAttr.Name
+				}
+			}
+			r.Provider = ProviderRef{
+				Name:  providerName,
+				Alias: alias,
+			}
+		} else {
+			diags = append(diags, opts.diagnostic("invalid-provider-reference", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid provider reference",
+				Detail:   "Provider argument requires a provider name followed by an optional alias, like \"aws.foo\".",
+				Subject:  attr.Expr.Range().Ptr(),
+			}))
+		}
+	} else {
+		// If provider _isn't_ set then we'll infer it from the
+		// resource type.
+		r.Provider = ProviderRef{
+			Name: resourceTypeDefaultProviderName(r.Type),
+		}
+	}
+
+	return r, diags
+}
+
 // ResourceMode represents the "mode" of a resource, which is used to
 // distinguish between managed resources ("resource" blocks in config) and
 // data resources ("data" blocks in config).
@@ -21,6 +21,10 @@ var rootSchema = &hcl.BodySchema{
 			Type:       "output",
 			LabelNames: []string{"name"},
 		},
+		{
+			Type:       "locals",
+			LabelNames: nil,
+		},
 		{
 			Type:       "provider",
 			LabelNames: []string{"name"},
@@ -37,6 +41,22 @@ var rootSchema = &hcl.BodySchema{
 			Type:       "module",
 			LabelNames: []string{"name"},
 		},
+		{
+			Type:       "check",
+			LabelNames: []string{"name"},
+		},
+		{
+			Type:       "moved",
+			LabelNames: nil,
+		},
+		{
+			Type:       "removed",
+			LabelNames: nil,
+		},
+		{
+			Type:       "import",
+			LabelNames: nil,
+		},
 	},
 }
 
@@ -45,11 +65,55 @@ var terraformBlockSchema = &hcl.BodySchema{
 		{
 			Name: "required_version",
 		},
+		{
+			Name: "experiments",
+		},
 	},
 	Blocks: []hcl.BlockHeaderSchema{
 		{
 			Type: "required_providers",
 		},
+		{
+			Type:       "backend",
+			LabelNames: []string{"type"},
+		},
+		{
+			Type: "cloud",
+		},
+		{
+			Type:       "provider_meta",
+			LabelNames: []string{"provider"},
+		},
+	},
+}
+
+var cloudSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "organization",
+		},
+		{
+			Name: "hostname",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "workspaces",
+		},
+	},
+}
+
+var cloudWorkspacesSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "name",
+		},
+		{
+			Name: "tags",
+		},
+		{
+			Name: "project",
+		},
 	},
 }
 
@@ -78,17 +142,55 @@ var variableSchema = &hcl.BodySchema{
 		{
 			Name: "sensitive",
 		},
+		{
+			Name: "nullable",
+		},
+		{
+			Name: "ephemeral",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "validation",
+		},
+	},
+}
+
+var checkRuleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "condition",
+			Required: true,
+		},
+		{
+			Name:     "error_message",
+			Required: true,
+		},
 	},
 }
 
 var outputSchema = &hcl.BodySchema{
 	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "value",
+		},
 		{
 			Name: "description",
 		},
 		{
 			Name: "sensitive",
 		},
+		{
+			Name: "ephemeral",
+		},
+		{
+			Name: "depends_on",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "precondition",
+		},
 	},
 }
 
@@ -103,6 +205,15 @@ var moduleCallSchema = &hcl.BodySchema{
 		{
 			Name: "providers",
 		},
+		{
+			Name: "count",
+		},
+		{
+			Name: "for_each",
+		},
+		{
+			Name: "depends_on",
+		},
 	},
 }
 
@@ -111,5 +222,110 @@ var resourceSchema = &hcl.BodySchema{
 		{
 			Name: "provider",
 		},
+		{
+			Name: "count",
+		},
+		{
+			Name: "for_each",
+		},
+		{
+			Name: "depends_on",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "lifecycle",
+		},
+	},
+}
+
+var lifecycleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "create_before_destroy",
+		},
+		{
+			Name: "prevent_destroy",
+		},
+		{
+			Name: "ignore_changes",
+		},
+		{
+			Name: "replace_triggered_by",
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "precondition",
+		},
+		{
+			Type: "postcondition",
+		},
+	},
+}
+
+var checkBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type:       "data",
+			LabelNames: []string{"type", "name"},
+		},
+		{
+			Type: "assert",
+		},
+	},
+}
+
+var movedSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "from",
+			Required: true,
+		},
+		{
+			Name:     "to",
+			Required: true,
+		},
+	},
+}
+
+var removedSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "from",
+			Required: true,
+		},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{
+			Type: "lifecycle",
+		},
+	},
+}
+
+var removedLifecycleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "destroy",
+		},
+	},
+}
+
+var importSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name:     "to",
+			Required: true,
+		},
+		{
+			Name:     "id",
+			Required: true,
+		},
+		{
+			Name: "for_each",
+		},
+		{
+			Name: "provider",
+		},
 	},
 }
@@ -0,0 +1,209 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// DefaultSensitiveResourceAttributes returns the attributes of some common
+// resource types that their providers mark as sensitive, by resource type.
+// The same table applies to both managed and data resources.
+//
+// Without access to provider schemas this is necessarily incomplete, so
+// callers may add entries for other resource types they care about to the
+// result, which is a new map on each call, before passing it to
+// Module.ValidateOutputSensitivity.
+func DefaultSensitiveResourceAttributes() map[string][]string {
+	ret := make(map[string][]string, len(defaultSensitiveResourceAttributes))
+	for resourceType, attrs := range defaultSensitiveResourceAttributes {
+		ret[resourceType] = append([]string(nil), attrs...)
+	}
+	return ret
+}
+
+var defaultSensitiveResourceAttributes = map[string][]string{
+	"aws_db_instance":                   {"password"},
+	"aws_iam_access_key":                {"secret", "ses_smtp_password_v4"},
+	"aws_rds_cluster":                   {"master_password"},
+	"aws_secretsmanager_secret_version": {"secret_string", "secret_binary"},
+	"aws_ssm_parameter":                 {"value"},
+	"azurerm_storage_account":           {"primary_access_key", "secondary_access_key", "primary_connection_string", "secondary_connection_string"},
+	"google_service_account_key":        {"private_key"},
+	"kubernetes_secret":                 {"data"},
+	"random_password":                   {"result", "bcrypt_hash"},
+	"tls_private_key":                   {"private_key_pem", "private_key_openssh", "private_key_pem_pkcs8"},
+}
+
+// ValidateOutputSensitivity checks for outputs whose values derive from
+// sensitive values, either directly or through local values, but that are
+// not themselves marked as sensitive, returning a warning diagnostic for
+// each.
+//
+// Values are considered sensitive if they come from input variables marked
+// as sensitive, from the given sensitive resource attributes, by resource
+// type, or from calls to the sensitive function. If resourceAttrs is nil
+// then the result of DefaultSensitiveResourceAttributes is used. The
+// nonsensitive function is assumed to remove sensitivity only when it wraps
+// an entire expression.
+//
+// This doesn't use the module's dependency graph, because the graph records
+// only which objects depend on each other, while sensitivity depends on
+// which attributes of a resource are used and on the chain of local values
+// that a sensitive value passes through.
+func (m *Module) ValidateOutputSensitivity(resourceAttrs map[string][]string) Diagnostics {
+	var diags Diagnostics
+
+	if resourceAttrs == nil {
+		resourceAttrs = defaultSensitiveResourceAttributes
+	}
+
+	// origins records the sensitive value that each sensitive local value
+	// derives from. Local values can refer to each other, so we repeat
+	// until there are no further changes.
+	origins := make(map[string]*sensitiveOrigin)
+	for changed := true; changed; {
+		changed = false
+		for _, name := range sortedKeys(m.Locals) {
+			addr := "local." + name
+			if _, sensitive := origins[addr]; sensitive {
+				continue
+			}
+			expr := m.Locals[name].Expression
+			if expr == nil {
+				continue
+			}
+			if origin := m.sensitiveOrigin(expr, origins, resourceAttrs); origin != nil {
+				origins[addr] = origin
+				changed = true
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(m.Outputs) {
+		o := m.Outputs[name]
+		if o.Sensitive || o.Value == nil {
+			continue
+		}
+		origin := m.sensitiveOrigin(o.Value, origins, resourceAttrs)
+		if origin == nil {
+			continue
+		}
+		pos := o.Pos
+		diags = append(diags, Diagnostic{
+			Severity: DiagWarning,
+			Code:     "sensitive-output-value",
+			Summary:  "Output refers to sensitive values",
+			Detail:   fmt.Sprintf("The value of output %q derives from %s, but the output is not marked as sensitive. Set sensitive = true in the output block, or wrap the value in nonsensitive() if exposing it is intended.", name, origin.String()),
+			Pos:      &pos,
+		})
+	}
+
+	return diags
+}
+
+// sensitiveOrigin describes the sensitive value that some other value
+// derives from.
+type sensitiveOrigin struct {
+	// Source describes the sensitive value itself.
+	Source string
+
+	// Via are the addresses of the local values that the value passes
+	// through, starting with the one closest to the derived value.
+	Via []string
+}
+
+func (o *sensitiveOrigin) String() string {
+	if len(o.Via) == 0 {
+		return o.Source
+	}
+	return fmt.Sprintf("%s via %s", o.Source, strings.Join(o.Via, ", "))
+}
+
+// sensitiveOrigin returns the first sensitive value that the given
+// expression derives from, or nil if the expression is not sensitive.
+// localOrigins records the local values already known to be sensitive, and
+// resourceAttrs the sensitive resource attributes by resource type.
+func (m *Module) sensitiveOrigin(expr *Expression, localOrigins map[string]*sensitiveOrigin, resourceAttrs map[string][]string) *sensitiveOrigin {
+	if call, ok := expr.Expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "nonsensitive" {
+		return nil
+	}
+
+	if syntaxExpr, ok := expr.Expr.(hclsyntax.Expression); ok {
+		var found bool
+		hclsyntax.VisitAll(syntaxExpr, func(node hclsyntax.Node) hcl.Diagnostics {
+			if call, ok := node.(*hclsyntax.FunctionCallExpr); ok && call.Name == "sensitive" {
+				found = true
+			}
+			return nil
+		})
+		if found {
+			return &sensitiveOrigin{Source: "a value marked by the sensitive function"}
+		}
+	}
+
+	for _, ref := range expr.References {
+		switch ref.Kind {
+		case VariableReferenceKind:
+			v, exists := m.Variables[ref.Subject[len("var."):]]
+			if exists && v.Sensitive {
+				return &sensitiveOrigin{Source: "the sensitive variable " + ref.Subject}
+			}
+		case LocalReferenceKind:
+			if origin, sensitive := localOrigins[ref.Subject]; sensitive {
+				return &sensitiveOrigin{
+					Source: origin.Source,
+					Via:    append([]string{ref.Subject}, origin.Via...),
+				}
+			}
+		case ManagedResourceReferenceKind, DataResourceReferenceKind:
+			if attr, sensitive := sensitiveResourceAttribute(ref, resourceAttrs); sensitive {
+				return &sensitiveOrigin{Source: "the sensitive resource attribute " + attr}
+			}
+		}
+	}
+	return nil
+}
+
+// sensitiveResourceAttribute returns the address of the attribute that the
+// given resource reference refers to and whether that attribute is one of
+// the given sensitive attributes. A reference to a whole resource is
+// sensitive if the resource has any sensitive attributes.
+func sensitiveResourceAttribute(ref Reference, resourceAttrs map[string][]string) (string, bool) {
+	typeStep := 0
+	if ref.Kind == DataResourceReferenceKind {
+		typeStep = 1
+	}
+	if len(ref.Traversal) <= typeStep+1 {
+		return "", false
+	}
+	resourceType := strings.Split(ref.Subject, ".")[typeStep]
+	sensitiveAttrs := resourceAttrs[resourceType]
+	if len(sensitiveAttrs) == 0 {
+		return "", false
+	}
+
+	// The attribute name follows the resource name, possibly after an
+	// index step for resources using count or for_each.
+	for _, step := range ref.Traversal[typeStep+2:] {
+		if _, isIndex := step.(hcl.TraverseIndex); isIndex {
+			continue
+		}
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		for _, name := range sensitiveAttrs {
+			if attr.Name == name {
+				return ref.Subject + "." + name, true
+			}
+		}
+		return "", false
+	}
+	return ref.Subject, true
+}
@@ -0,0 +1,64 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestModuleValidateOutputSensitivity(t *testing.T) {
+	mod, diags := LoadModule("testdata/sensitive-outputs")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors loading module: %s", diags.Error())
+	}
+
+	type diagSummary struct {
+		Severity DiagSeverity
+		Line     int
+		Detail   string
+	}
+	var got []diagSummary
+	for _, diag := range mod.ValidateOutputSensitivity(nil) {
+		if diag.Summary != "Output refers to sensitive values" {
+			t.Errorf("wrong summary %q", diag.Summary)
+		}
+		got = append(got, diagSummary{diag.Severity, diag.Pos.Line, diag.Detail})
+	}
+	const advice = ", but the output is not marked as sensitive. Set sensitive = true in the output block, or wrap the value in nonsensitive() if exposing it is intended."
+	want := []diagSummary{
+		{DiagWarning, 46, `The value of output "access_key_secret" derives from the sensitive resource attribute aws_iam_access_key.ci.secret` + advice},
+		{DiagWarning, 38, `The value of output "admin_password" derives from the sensitive resource attribute random_password.admin.result` + advice},
+		{DiagWarning, 50, `The value of output "api_key" derives from a value marked by the sensitive function via local.api_key` + advice},
+		{DiagWarning, 25, `The value of output "connection_string" derives from the sensitive variable var.db_password via local.connection_string, local.credentials` + advice},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+}
+
+func TestModuleValidateOutputSensitivityResourceAttributes(t *testing.T) {
+	mod, diags := LoadModule("testdata/sensitive-outputs")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors loading module: %s", diags.Error())
+	}
+
+	var got []int
+	for _, diag := range mod.ValidateOutputSensitivity(map[string][]string{"random_password": {"id"}}) {
+		got = append(got, diag.Pos.Line)
+	}
+	// Only the given table applies, so random_password.admin.result and
+	// aws_iam_access_key.ci[0].secret are no longer sensitive.
+	want := []int{42, 50, 25}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wrong diagnostic lines\n%s", diff)
+	}
+
+	attrs := DefaultSensitiveResourceAttributes()
+	attrs["random_password"] = append(attrs["random_password"], "id")
+	if got := DefaultSensitiveResourceAttributes()["random_password"]; len(got) != 2 {
+		t.Errorf("changing the result changed the defaults to %#v", got)
+	}
+}
@@ -17,20 +17,54 @@ import (
 type SourcePos struct {
 	Filename string `json:"filename"`
 	Line     int    `json:"line"`
+
+	// Range is the exact range of the definition, for callers that need
+	// more than a line number. It is recorded only when requested with
+	// LoadOptions.SourceRanges, and is otherwise nil for compatibility.
+	//
+	// Ranges are exact for configuration loaded with the current HCL
+	// parser. The legacy parser records only where each definition starts,
+	// so for configuration that needs it the range is empty, starting and
+	// ending at the same location.
+	Range *SourceRange `json:"range,omitempty"`
+}
+
+// SourceRange is a range of characters within a source file, ending just
+// after its last character.
+type SourceRange struct {
+	Start SourceLocation `json:"start"`
+	End   SourceLocation `json:"end"`
 }
 
-func sourcePosHCL(rng hcl.Range) SourcePos {
-	// We intentionally throw away the column information here because
+// SourceLocation is a single location within a source file. Line and Column
+// both start at one, while Byte is the zero-based offset in bytes from the
+// start of the file.
+type SourceLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+func sourcePosHCL(rng hcl.Range, opts *decodeOptions) SourcePos {
+	// We intentionally use only the line in the main position here because
 	// current and legacy HCL both disagree on the definition of a column
 	// and so a line-only reference is the best granularity we can do
-	// such that the result is consistent between both parsers.
-	return SourcePos{
+	// such that the result is consistent between both parsers. The full
+	// range is available separately for callers that ask for it.
+	pos := SourcePos{
 		Filename: rng.Filename,
 		Line:     rng.Start.Line,
 	}
+	if opts.SourceRanges {
+		pos.Range = &SourceRange{
+			Start: SourceLocation{Line: rng.Start.Line, Column: rng.Start.Column, Byte: rng.Start.Byte},
+			End:   SourceLocation{Line: rng.End.Line, Column: rng.End.Column, Byte: rng.End.Byte},
+		}
+	}
+	return pos
 }
 
-func sourcePosLegacyHCL(pos legacyhcltoken.Pos, filename string) SourcePos {
+func sourcePosLegacyHCL(pos legacyhcltoken.Pos, filename string, opts *decodeOptions) SourcePos {
 	useFilename := pos.Filename
 	// We'll try to use the filename given in legacy HCL position, but
 	// in practice there's no way to actually get this populated via
@@ -39,8 +73,13 @@ func sourcePosLegacyHCL(pos legacyhcltoken.Pos, filename string) SourcePos {
 	if useFilename == "" {
 		useFilename = filename
 	}
-	return SourcePos{
+	ret := SourcePos{
 		Filename: useFilename,
 		Line:     pos.Line,
 	}
+	if opts.SourceRanges {
+		loc := SourceLocation{Line: pos.Line, Column: pos.Column, Byte: pos.Offset}
+		ret.Range = &SourceRange{Start: loc, End: loc}
+	}
+	return ret
 }
@@ -0,0 +1,112 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+func TestLoadModuleWithOptionsSourceRanges(t *testing.T) {
+	mod, _ := LoadModuleWithOptions(NewOsFs(), "testdata/basics", LoadOptions{SourceRanges: true})
+
+	want := &SourceRange{
+		Start: SourceLocation{Line: 13, Column: 1, Byte: 150},
+		End:   SourceLocation{Line: 13, Column: 11, Byte: 160},
+	}
+	if diff := cmp.Diff(want, mod.Outputs["A"].Pos.Range); diff != "" {
+		t.Errorf("wrong output range\n%s", diff)
+	}
+
+	want = &SourceRange{
+		Start: SourceLocation{Line: 14, Column: 5, Byte: 167},
+		End:   SourceLocation{Line: 14, Column: 23, Byte: 185},
+	}
+	if diff := cmp.Diff(want, mod.Outputs["A"].AttributeSources["value"].Range); diff != "" {
+		t.Errorf("wrong value argument range\n%s", diff)
+	}
+
+	_, diags := LoadModuleWithOptions(NewOsFs(), "testdata/syntax-error", LoadOptions{SourceRanges: true})
+	if len(diags) == 0 || diags[0].Pos == nil || diags[0].Pos.Range == nil {
+		t.Fatalf("missing range for diagnostic %#v", diags)
+	}
+
+	// The legacy parser only records where each definition starts.
+	mod, _ = LoadModuleWithOptions(NewOsFs(), "testdata/invalid-braces", LoadOptions{SourceRanges: true})
+	rng := mod.Variables["foo"].Pos.Range
+	if rng == nil || rng.Start.Line != 5 || rng.Start != rng.End {
+		t.Errorf("wrong legacy variable range %#v", rng)
+	}
+}
+
+func TestLoadModuleWithoutSourceRanges(t *testing.T) {
+	mod, diags := LoadModule("testdata/basics")
+	if rng := mod.Outputs["A"].Pos.Range; rng != nil {
+		t.Errorf("unexpected output range %#v", rng)
+	}
+	if rng := mod.Outputs["A"].AttributeSources["value"].Range; rng != nil {
+		t.Errorf("unexpected value argument range %#v", rng)
+	}
+
+	_, diags = LoadModule("testdata/syntax-error")
+	for _, diag := range diags {
+		if diag.Pos != nil && diag.Pos.Range != nil {
+			t.Errorf("unexpected range for diagnostic %q", diag.Summary)
+		}
+	}
+}
+
+func TestLoadModuleTreeWithOptionsSourceRanges(t *testing.T) {
+	tree, _ := LoadModuleTreeWithOptions(WrapFS(os.DirFS(".")), "testdata/module-tree", LoadOptions{SourceRanges: true})
+	node := tree.Modules["module.network"]
+	if node == nil {
+		t.Fatalf("no module.network in the tree")
+	}
+	for name, v := range node.Module.Variables {
+		if v.Pos.Range == nil {
+			t.Errorf("missing range for variable %q of module.network", name)
+		}
+	}
+
+	tree, _ = LoadModuleTree("testdata/module-tree")
+	for name, v := range tree.Modules["module.network"].Module.Variables {
+		if v.Pos.Range != nil {
+			t.Errorf("unexpected range for variable %q of module.network", name)
+		}
+	}
+}
+
+func TestLoadVariableValuesWithOptionsSourceRanges(t *testing.T) {
+	const path = "testdata/variable-values/terraform.tfvars"
+	values, _ := LoadVariableValuesWithOptions(NewOsFs(), LoadOptions{SourceRanges: true}, path)
+	want := &SourceRange{
+		Start: SourceLocation{Line: 1, Column: 1, Byte: 0},
+		End:   SourceLocation{Line: 1, Column: 7, Byte: 6},
+	}
+	if diff := cmp.Diff(want, values["region"].Pos.Range); diff != "" {
+		t.Errorf("wrong region range\n%s", diff)
+	}
+
+	values, _ = LoadVariableValues(NewOsFs(), path)
+	if rng := values["region"].Pos.Range; rng != nil {
+		t.Errorf("unexpected region range %#v", rng)
+	}
+}
+
+func TestLoadModuleFromFileWithoutSourceRanges(t *testing.T) {
+	file, diags := hclparse.NewParser().ParseHCLFile("testdata/basics/basics.tf")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+	mod := NewModule("testdata/basics")
+	if diags := LoadModuleFromFile(file, mod); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+	if rng := mod.Outputs["A"].Pos.Range; rng != nil {
+		t.Errorf("unexpected output range %#v", rng)
+	}
+}
@@ -0,0 +1,17 @@
+terraform {
+  required_version = ">= 1.5.0"
+
+  experiments = [module_variable_optional_attrs]
+
+  backend "s3" {
+    bucket         = "acme-terraform-state"
+    key            = "network/prod.tfstate"
+    region         = "us-east-1"
+    dynamodb_table = "terraform-locks"
+    encrypt        = true
+  }
+
+  provider_meta "aws" {
+    module_name = "network"
+  }
+}
@@ -0,0 +1,44 @@
+resource "aws_lb" "main" {
+  name = "main"
+}
+
+check "health" {
+  data "http" "endpoint" {
+    url = "https://${aws_lb.main.dns_name}/health"
+  }
+
+  assert {
+    condition     = data.http.endpoint.status_code == 200
+    error_message = "The health endpoint returned ${data.http.endpoint.status_code}."
+  }
+
+  assert {
+    condition     = can(jsondecode(data.http.endpoint.response_body))
+    error_message = "The health endpoint did not return JSON."
+  }
+}
+
+check "certificate" {
+  assert {
+    condition     = aws_lb.main.load_balancer_type == "application"
+    error_message = "The load balancer must be an application load balancer."
+  }
+}
+
+check "empty" {
+}
+
+check "duplicate" {
+  data "http" "a" {
+    url = "https://example.com/a"
+  }
+
+  data "http" "b" {
+    url = "https://example.com/b"
+  }
+
+  assert {
+    condition     = data.http.a.status_code == 200
+    error_message = "Endpoint a is down."
+  }
+}
@@ -0,0 +1,11 @@
+terraform {
+  cloud {
+    organization = "acme"
+    hostname     = "app.terraform.io"
+
+    workspaces {
+      tags    = ["app", "prod"]
+      project = "platform"
+    }
+  }
+}
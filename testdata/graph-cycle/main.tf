@@ -0,0 +1,14 @@
+locals {
+  a = local.b
+  b = local.a
+}
+
+resource "null_resource" "self" {
+  triggers = {
+    id = null_resource.self.id
+  }
+}
+
+output "a" {
+  value = local.a
+}
@@ -0,0 +1,57 @@
+variable "cidr_block" {
+  type = string
+}
+
+variable "environment" {
+  type    = string
+  default = "dev"
+}
+
+locals {
+  name = "app-${var.environment}"
+  tags = {
+    Name        = local.name
+    Environment = var.environment
+  }
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = var.cidr_block
+  tags       = local.tags
+}
+
+resource "aws_subnet" "public" {
+  count      = 2
+  vpc_id     = aws_vpc.main.id
+  cidr_block = cidrsubnet(var.cidr_block, 8, count.index)
+
+  timeouts {
+    create = "5m"
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  subnet_id     = aws_subnet.public[0].id
+  instance_type = "t3.micro"
+
+  depends_on = [module.dns]
+}
+
+module "dns" {
+  source = "./modules/dns"
+
+  vpc_id = aws_vpc.main.id
+}
+
+output "instance_ip" {
+  value = aws_instance.web.private_ip
+}
+
+output "zone_id" {
+  value = module.dns.zone_id
+}
@@ -0,0 +1,27 @@
+# The opening brace on its own line is invalid in HCL 2, so this module is
+# loaded by the legacy parser, which must still merge the override file
+# argument by argument.
+
+variable "region"
+{
+  default     = "us-east-1"
+  description = "The region"
+}
+
+output "region" {
+  value       = "${var.region}"
+  description = "The region in use"
+}
+
+resource "aws_instance" "web" {
+  provider = "aws.east"
+}
+
+module "network" {
+  source  = "hashicorp/network/aws"
+  version = "1.0.0"
+}
+
+provider "aws" {
+  version = "~> 4.0"
+}
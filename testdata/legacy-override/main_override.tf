@@ -0,0 +1,15 @@
+variable "region" {
+  description = "The region, overridden"
+}
+
+output "region" {
+  sensitive = true
+}
+
+module "network" {
+  version = "2.0.0"
+}
+
+provider "aws" {
+  version = "~> 5.0"
+}
@@ -0,0 +1,15 @@
+variable "environment" {
+  default = "dev"
+}
+
+locals {
+  region = "us-east-1"
+  tags = {
+    Environment = var.environment
+  }
+}
+
+locals {
+  name_prefix = "${var.environment}-${local.region}"
+  created     = timestamp()
+}
@@ -0,0 +1,70 @@
+variable "buckets" {
+  type = set(string)
+}
+
+resource "aws_s3_bucket" "this" {
+  for_each = var.buckets
+  bucket   = each.value
+
+  lifecycle {
+    prevent_destroy       = true
+    create_before_destroy = true
+    ignore_changes        = [tags, tags["Owner"]]
+    replace_triggered_by  = [null_resource.rotate.id]
+
+    precondition {
+      condition     = length(each.value) <= 63
+      error_message = "Bucket names must be at most 63 characters."
+    }
+
+    postcondition {
+      condition     = self.region == "us-east-1"
+      error_message = "Buckets must be created in us-east-1."
+    }
+  }
+}
+
+resource "null_resource" "rotate" {
+  count = 1
+
+  lifecycle {
+    ignore_changes = all
+  }
+}
+
+resource "aws_db_instance" "legacy" {
+  depends_on = [aws_s3_bucket.this]
+
+  lifecycle {
+    ignore_changes = ["password", "*"]
+  }
+}
+
+data "aws_caller_identity" "current" {
+  depends_on = [null_resource.rotate]
+
+  lifecycle {
+    postcondition {
+      condition     = self.account_id != ""
+      error_message = "Unable to determine the account ID."
+    }
+  }
+}
+
+module "per_bucket" {
+  source   = "./modules/bucket"
+  for_each = aws_s3_bucket.this
+
+  name       = each.key
+  depends_on = [null_resource.rotate]
+}
+
+resource "null_resource" "twice" {
+  lifecycle {
+    prevent_destroy = true
+  }
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
@@ -0,0 +1,34 @@
+variable "name" {
+  type = string
+}
+
+module "valid" {
+  source = "./modules/child"
+
+  count          = 2
+  name           = "${var.name}-${count.index}"
+  instance_count = "3"
+  ports          = [80, 443]
+  settings = {
+    size = "small"
+  }
+  zone = "a"
+}
+
+module "invalid" {
+  source = "./modules/child"
+
+  name           = var.name
+  instance_count = "many"
+  settings = {
+    enabled = false
+  }
+  zone  = null
+  color = "blue"
+}
+
+module "missing" {
+  source = "./modules/child"
+
+  depends_on = [module.valid]
+}
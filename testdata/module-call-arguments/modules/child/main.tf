@@ -0,0 +1,25 @@
+variable "name" {
+  type = string
+}
+
+variable "instance_count" {
+  type    = number
+  default = 1
+}
+
+variable "ports" {
+  type = list(number)
+}
+
+variable "settings" {
+  type = object({
+    size    = string
+    enabled = optional(bool, true)
+  })
+  default = null
+}
+
+variable "zone" {
+  type     = string
+  nullable = false
+}
@@ -0,0 +1,25 @@
+provider "aws" {
+  region = "us-east-1"
+}
+
+provider "aws" {
+  alias  = "usw2"
+  region = "us-west-2"
+}
+
+module "network" {
+  source = "./network"
+
+  providers = {
+    aws      = aws
+    aws.west = aws.usw2
+  }
+}
+
+module "invalid" {
+  source = "./invalid"
+
+  providers = {
+    aws = aws.usw2["nope"]
+  }
+}
@@ -0,0 +1,3 @@
+variable "cluster_size" {
+  default = 3
+}
@@ -0,0 +1,23 @@
+module "network" {
+  source = "./modules/network"
+
+  cidr_block = "10.0.0.0/16"
+}
+
+module "missing" {
+  source = "./modules/missing"
+}
+
+module "consul" {
+  source  = "hashicorp/consul/aws"
+  version = "0.1.0"
+}
+
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+
+module "dns" {
+  source = "github.com/example/terraform-dns"
+  zone   = "example.com"
+}
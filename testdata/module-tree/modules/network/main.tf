@@ -0,0 +1,7 @@
+variable "cidr_block" {
+  type = string
+}
+
+module "subnets" {
+  source = "../subnets"
+}
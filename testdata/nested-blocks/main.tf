@@ -0,0 +1,35 @@
+variable "ports" {
+  type    = list(number)
+  default = [80, 443]
+}
+
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port = 22
+    to_port   = 22
+    protocol  = "tcp"
+  }
+
+  dynamic "ingress" {
+    for_each = var.ports
+    content {
+      from_port = ingress.value
+      to_port   = ingress.value
+      protocol  = "tcp"
+    }
+  }
+
+  lifecycle {
+    create_before_destroy = true
+  }
+}
+
+resource "aws_instance" "web" {
+  ami = "ami-123456"
+
+  provisioner "local-exec" {
+    command = "echo hello"
+  }
+}
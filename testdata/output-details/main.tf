@@ -0,0 +1,30 @@
+resource "aws_instance" "web" {
+  ami           = "ami-123456"
+  instance_type = "t3.micro"
+}
+
+resource "aws_eip" "web" {
+  instance = aws_instance.web.id
+}
+
+output "public_ip" {
+  description = "The public IP address of the web server"
+  value       = aws_eip.web.public_ip
+
+  depends_on = [aws_instance.web]
+
+  precondition {
+    condition     = aws_eip.web.domain == "vpc"
+    error_message = "The elastic IP must be allocated in a VPC."
+  }
+}
+
+output "session" {
+  value     = "static"
+  ephemeral = true
+}
+
+output "bad_depends_on" {
+  value      = aws_instance.web.id
+  depends_on = [aws_instance.web.id[0], "aws_eip.web"]
+}
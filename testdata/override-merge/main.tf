@@ -0,0 +1,98 @@
+terraform {
+  required_version = ">= 1.3.0"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 4.0"
+    }
+  }
+
+  backend "s3" {
+    bucket = "acme-terraform-state"
+    key    = "app.tfstate"
+  }
+}
+
+provider "aws" {
+  region  = "us-east-1"
+  profile = "default"
+
+  assume_role {
+    role_arn = "arn:aws:iam::123456789012:role/base"
+  }
+}
+
+variable "instance_type" {
+  type        = string
+  default     = "t3.micro"
+  description = "The instance type"
+}
+
+locals {
+  name = "app"
+  env  = "dev"
+}
+
+resource "aws_instance" "web" {
+  count         = 2
+  ami           = "ami-base"
+  instance_type = var.instance_type
+
+  ebs_block_device {
+    device_name = "/dev/sdb"
+  }
+
+  ebs_block_device {
+    device_name = "/dev/sdc"
+  }
+
+  root_block_device {
+    volume_size = 20
+  }
+
+  lifecycle {
+    create_before_destroy = true
+    ignore_changes        = [tags]
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+  owners      = ["099720109477"]
+}
+
+module "network" {
+  source  = "hashicorp/network/aws"
+  version = "1.0.0"
+
+  cidr_block = "10.0.0.0/16"
+  name       = local.name
+}
+
+output "instance_ids" {
+  value       = aws_instance.web[*].id
+  description = "The instance IDs"
+}
+
+provider "google" {
+  version = "~> 4.0"
+  project = "acme"
+}
+
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port = 22
+    to_port   = 22
+  }
+
+  dynamic "egress" {
+    for_each = [443]
+    content {
+      from_port = egress.value
+      to_port   = egress.value
+    }
+  }
+}
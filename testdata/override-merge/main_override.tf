@@ -0,0 +1,69 @@
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+
+  cloud {
+    organization = "acme"
+
+    workspaces {
+      name = "app"
+    }
+  }
+}
+
+provider "aws" {
+  region = "eu-west-1"
+}
+
+variable "instance_type" {
+  description = "The instance type, overridden"
+}
+
+locals {
+  env = "prod"
+}
+
+resource "aws_instance" "web" {
+  for_each = toset(["a", "b"])
+  ami      = "ami-override"
+
+  ebs_block_device {
+    device_name = "/dev/sdd"
+  }
+
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+
+data "aws_ami" "ubuntu" {
+  owners = ["self"]
+}
+
+module "network" {
+  version = "2.0.0"
+
+  cidr_block = "10.1.0.0/16"
+}
+
+output "instance_ids" {
+  sensitive = true
+}
+
+provider "google" {
+  version = "~> 5.0"
+}
+
+resource "aws_security_group" "web" {
+  dynamic "ingress" {
+    for_each = [80, 443]
+    content {
+      from_port = ingress.value
+      to_port   = ingress.value
+    }
+  }
+}
@@ -0,0 +1,16 @@
+resource "aws_instance" "db" {
+  ami = "ami-db"
+}
+
+module "dns" {
+  version = "1.0.0"
+}
+
+locals {
+  region = "us-east-1"
+}
+
+moved {
+  from = aws_instance.old
+  to   = aws_instance.web
+}
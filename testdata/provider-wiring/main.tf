@@ -0,0 +1,53 @@
+terraform {
+  required_providers {
+    aws = {
+      source = "hashicorp/aws"
+    }
+    google = {
+      source = "hashicorp/google"
+    }
+  }
+}
+
+provider "aws" {
+  alias = "usw2"
+}
+
+module "ok" {
+  source = "./modules/multi-region"
+
+  providers = {
+    aws      = aws
+    aws.west = aws.usw2
+  }
+}
+
+module "missing_alias" {
+  source = "./modules/multi-region"
+
+  providers = {
+    aws = aws
+  }
+}
+
+module "undefined_config" {
+  source = "./modules/multi-region"
+
+  providers = {
+    aws      = aws
+    aws.west = aws.euw1
+  }
+}
+
+module "wrong_provider" {
+  source = "./modules/multi-region"
+
+  providers = {
+    aws      = google
+    aws.west = aws.usw2
+  }
+}
+
+module "implicit" {
+  source = "./modules/fork"
+}
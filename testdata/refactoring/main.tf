@@ -0,0 +1,74 @@
+resource "aws_instance" "web" {
+  count = 2
+}
+
+resource "aws_s3_bucket" "logs" {
+  for_each = toset(["a", "b"])
+}
+
+module "network" {
+  source = "./network"
+}
+
+moved {
+  from = aws_instance.app
+  to   = aws_instance.web[0]
+}
+
+moved {
+  from = module.vpc["east"]
+  to   = module.network
+}
+
+moved {
+  from = module.old.aws_subnet.public
+  to   = module.network.aws_subnet.public[1]
+}
+
+moved {
+  from = aws_instance.old
+  to   = aws_instance.missing
+}
+
+moved {
+  from = aws_instance.loop_a
+  to   = aws_instance.loop_b
+}
+
+moved {
+  from = aws_instance.loop_b
+  to   = aws_instance.loop_a
+}
+
+removed {
+  from = aws_instance.legacy
+
+  lifecycle {
+    destroy = false
+  }
+}
+
+removed {
+  from = module.network.aws_route.default
+}
+
+removed {
+  from = aws_instance.web
+}
+
+import {
+  to = aws_instance.web[1]
+  id = "i-0123456789"
+}
+
+import {
+  for_each = { a = "bucket-a", b = "bucket-b" }
+  to       = aws_s3_bucket.logs[each.key]
+  id       = each.value
+  provider = aws.west
+}
+
+import {
+  to = aws_instance.nope
+  id = "i-abcdef"
+}
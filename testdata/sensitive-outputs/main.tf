@@ -0,0 +1,56 @@
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+
+variable "db_user" {
+  type = string
+}
+
+locals {
+  credentials       = "${var.db_user}:${var.db_password}"
+  connection_string = "postgres://${local.credentials}@db.example.com/app"
+  api_key           = sensitive(file("${path.module}/api.key"))
+}
+
+resource "random_password" "admin" {
+  length = 32
+}
+
+resource "aws_iam_access_key" "ci" {
+  count = 1
+  user  = "ci"
+}
+
+output "connection_string" {
+  value = local.connection_string
+}
+
+output "connection_string_sensitive" {
+  value     = local.connection_string
+  sensitive = true
+}
+
+output "db_user" {
+  value = var.db_user
+}
+
+output "admin_password" {
+  value = random_password.admin.result
+}
+
+output "admin_password_id" {
+  value = random_password.admin.id
+}
+
+output "access_key_secret" {
+  value = aws_iam_access_key.ci[0].secret
+}
+
+output "api_key" {
+  value = local.api_key
+}
+
+output "explicitly_nonsensitive" {
+  value = nonsensitive(var.db_password)
+}
@@ -0,0 +1,33 @@
+variable "servers" {
+  type = map(object({
+    name     = string
+    size     = optional(string, "small")
+    ports    = optional(list(number), [80, 443])
+    tags     = optional(map(string))
+    location = tuple([string, number])
+  }))
+}
+
+variable "anything" {
+  type = any
+}
+
+variable "set_of_bools" {
+  type = set(bool)
+}
+
+variable "invalid_modifier" {
+  type = optional(string)
+}
+
+variable "missing_element_type" {
+  type = set
+}
+
+variable "bare_list" {
+  type = list
+}
+
+variable "bare_map" {
+  type = map
+}
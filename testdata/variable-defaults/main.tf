@@ -0,0 +1,49 @@
+variable "servers" {
+  type = map(object({
+    name  = string
+    size  = optional(string, "small")
+    ports = optional(list(number), [80])
+    tags  = optional(map(string))
+  }))
+  default = {
+    web = {
+      name = "web"
+    }
+    db = {
+      name  = "db"
+      size  = "large"
+      ports = ["5432"]
+    }
+  }
+}
+
+variable "count_from_string" {
+  type    = number
+  default = "3"
+}
+
+variable "wrong_primitive" {
+  type    = number
+  default = "three"
+}
+
+variable "missing_attribute" {
+  type = list(object({
+    name = string
+    size = optional(string)
+  }))
+  default = [
+    { name = "a" },
+    { size = "b" },
+  ]
+}
+
+variable "wrong_collection" {
+  type    = list(string)
+  default = { a = "b" }
+}
+
+variable "null_default" {
+  type    = string
+  default = null
+}
@@ -0,0 +1,37 @@
+variable "region" {
+  type        = string
+  description = "The region to deploy into"
+  nullable    = false
+
+  validation {
+    condition     = contains(["us-east-1", "us-west-2"], var.region)
+    error_message = "The region must be us-east-1 or us-west-2."
+  }
+}
+
+variable "instance_count" {
+  type    = number
+  default = 1
+
+  validation {
+    condition     = var.instance_count > 0
+    error_message = "The instance count must be positive."
+  }
+
+  validation {
+    condition     = var.instance_count <= var.max_instances
+    error_message = "The instance count must be at most ${var.max_instances}."
+  }
+}
+
+variable "max_instances" {
+  type    = number
+  default = 10
+}
+
+variable "session_token" {
+  type      = string
+  sensitive = true
+  ephemeral = true
+  nullable  = true
+}
@@ -0,0 +1,21 @@
+variable "region" {
+  type     = string
+  nullable = false
+}
+
+variable "instance_count" {
+  type    = number
+  default = 1
+}
+
+variable "servers" {
+  type = map(object({
+    size  = optional(string, "small")
+    ports = list(number)
+  }))
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
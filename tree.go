@@ -0,0 +1,256 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleTree is a tree of modules, starting at a root module and following
+// each of the module calls whose source could be resolved to a directory.
+type ModuleTree struct {
+	Root *ModuleNode `json:"root"`
+
+	// Modules contains all of the nodes in the tree, including the root,
+	// keyed by their module path.
+	Modules map[string]*ModuleNode `json:"-"`
+
+	// Diagnostics records any errors and warnings that were detected while
+	// loading the modules in the tree, including those reported for the
+	// individual modules.
+	Diagnostics Diagnostics `json:"diagnostics,omitempty"`
+}
+
+// ModuleNode is a single module within a ModuleTree.
+type ModuleNode struct {
+	// Path is the module path of the node, like "module.network.module.subnets".
+	// The root module has an empty path.
+	Path string `json:"path"`
+
+	Module *Module `json:"module"`
+
+	// Call is the module call in the parent module that this node was
+	// loaded for. It is nil for the root module.
+	Call *ModuleCall `json:"-"`
+
+	// Parent is the node for the module containing Call. It is nil for the
+	// root module.
+	Parent *ModuleNode `json:"-"`
+
+	// Children are the nodes for the module calls that could be resolved,
+	// keyed by the name of the call.
+	Children map[string]*ModuleNode `json:"children,omitempty"`
+}
+
+// LoadModuleTree reads the module in the given directory and then
+// recursively loads any child modules it calls, producing a tree of modules.
+func LoadModuleTree(dir string) (*ModuleTree, Diagnostics) {
+	return LoadModuleTreeFromFilesystem(NewOsFs(), dir)
+}
+
+// LoadModuleTreeFromFilesystem reads the module in the given directory in
+// the given FS and then recursively loads any child modules it calls,
+// producing a tree of modules.
+//
+// Module calls with local source paths, like "./modules/network", are
+// resolved relative to the calling module. Calls with any other source are
+// resolved using the module manifest that "terraform init" writes into the
+// root module directory, if present, and are otherwise left out of the tree.
+func LoadModuleTreeFromFilesystem(fs FS, dir string) (*ModuleTree, Diagnostics) {
+	return LoadModuleTreeWithOptions(fs, dir, LoadOptions{})
+}
+
+// LoadModuleTreeWithOptions is like LoadModuleTreeFromFilesystem, loading
+// each module in the tree as customized by the given options.
+func LoadModuleTreeWithOptions(fs FS, dir string, opts LoadOptions) (*ModuleTree, Diagnostics) {
+	mod, diags := LoadModuleWithOptions(fs, dir, opts)
+	manifest, manifestDiags := LoadModuleManifest(fs, dir)
+	diags = append(diags, manifestDiags...)
+	root := &ModuleNode{
+		Module: mod,
+	}
+	tree := &ModuleTree{
+		Root: root,
+		Modules: map[string]*ModuleNode{
+			"": root,
+		},
+	}
+
+	loader := &treeLoader{
+		fs:       fs,
+		rootDir:  dir,
+		manifest: manifest,
+		tree:     tree,
+		opts:     opts,
+	}
+	diags = append(diags, loader.loadChildren(root, []string{filepath.Clean(dir)})...)
+
+	tree.Diagnostics = diags
+	return tree, diags
+}
+
+type treeLoader struct {
+	fs       FS
+	rootDir  string
+	manifest ModuleManifest
+	tree     *ModuleTree
+	opts     LoadOptions
+}
+
+// loadChildren loads the child modules called from the module in the given
+// node, and then recursively loads their children too. ancestors is the list
+// of directories of the modules between the root and the given node, which
+// we use to detect module calls that would cause infinite recursion.
+func (l *treeLoader) loadChildren(node *ModuleNode, ancestors []string) Diagnostics {
+	var diags Diagnostics
+
+	for _, name := range sortedKeys(node.Module.ModuleCalls) {
+		call := node.Module.ModuleCalls[name]
+		path := childModulePath(node.Path, name)
+		pos := call.Pos
+
+		dir, dirDiags := l.moduleDir(node, call, path)
+		diags = append(diags, dirDiags...)
+		if dir == "" {
+			continue
+		}
+		if cycle := cyclePath(ancestors, dir); cycle != nil {
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "module-call-cycle",
+				Summary:  "Module call cycle",
+				Detail:   fmt.Sprintf("Module call %q would cause infinite recursion: %s.", name, strings.Join(cycle, " -> ")),
+				Pos:      &pos,
+			})
+			continue
+		}
+		if _, err := l.fs.ReadDir(dir); err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "module-not-found",
+				Summary:  "Module not found",
+				Detail:   fmt.Sprintf("The module directory %s for module call %q does not exist or cannot be read.", dir, name),
+				Pos:      &pos,
+			})
+			continue
+		}
+
+		mod, modDiags := LoadModuleWithOptions(l.fs, dir, l.opts)
+		diags = append(diags, modDiags...)
+
+		child := &ModuleNode{
+			Path:   path,
+			Module: mod,
+			Call:   call,
+			Parent: node,
+		}
+		if node.Children == nil {
+			node.Children = make(map[string]*ModuleNode)
+		}
+		node.Children[name] = child
+		l.tree.Modules[child.Path] = child
+
+		diags = append(diags, l.loadChildren(child, append(ancestors[:len(ancestors):len(ancestors)], dir))...)
+	}
+
+	return diags
+}
+
+// moduleDir returns the directory containing the module for the given call
+// in the given node, which has the given module path, or an empty string if
+// the call cannot be resolved to a directory.
+func (l *treeLoader) moduleDir(node *ModuleNode, call *ModuleCall, path string) (string, Diagnostics) {
+	switch addr := call.SourceAddr.(type) {
+	case nil:
+		// The source address is missing or invalid, which is reported
+		// when loading the calling module.
+		return "", nil
+	case ModuleSourceLocal:
+		return filepath.Join(node.Module.Path, addr.Path), nil
+	}
+
+	if l.manifest == nil {
+		// Remote modules can only be resolved once they are installed.
+		return "", nil
+	}
+
+	pos := call.Pos
+	record := l.manifest.Record(path)
+	if record == nil {
+		return "", Diagnostics{
+			{
+				Severity: DiagWarning,
+				Code:     "module-not-installed",
+				Summary:  "Module not installed",
+				Detail:   fmt.Sprintf("Module call %q has no record in the module manifest. Run \"terraform init\" to install all modules required by this configuration.", call.Name),
+				Pos:      &pos,
+			},
+		}
+	}
+
+	var diags Diagnostics
+	if !sameModuleSource(record.Source, call.Source) {
+		diags = append(diags, Diagnostic{
+			Severity: DiagWarning,
+			Code:     "module-source-changed",
+			Summary:  "Module source has changed",
+			Detail:   fmt.Sprintf("The source address for module call %q was changed since it was installed from %q. Run \"terraform init\" to install all modules required by this configuration.", call.Name, record.Source),
+			Pos:      &pos,
+		})
+	}
+	return filepath.Join(l.rootDir, record.Dir), diags
+}
+
+// sameModuleSource returns true if the given module source addresses refer
+// to the same module. Terraform records normalized addresses in the module
+// manifest, like "registry.terraform.io/hashicorp/consul/aws" for
+// "hashicorp/consul/aws", so the addresses must be compared after parsing.
+func sameModuleSource(a, b string) bool {
+	addrA, errA := ParseModuleSource(a)
+	addrB, errB := ParseModuleSource(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return normalizeModuleSource(addrA) == normalizeModuleSource(addrB)
+}
+
+// normalizeModuleSource returns the given module source address with the
+// details that don't affect which module it refers to removed.
+func normalizeModuleSource(addr ModuleSource) ModuleSource {
+	switch addr := addr.(type) {
+	case ModuleSourceRegistry:
+		addr.Host = strings.ToLower(addr.Host)
+		return addr
+	case ModuleSourceRemote:
+		// Terraform records shorthand addresses like "github.com/org/repo"
+		// with their detected getter forced, like "git::https://...".
+		addr.ForcedGetter = false
+		return addr
+	default:
+		return addr
+	}
+}
+
+// cyclePath returns the chain of directories that would form a cycle if the
+// given directory were added after the given ancestors, or nil if doing so
+// would not form a cycle.
+func cyclePath(ancestors []string, dir string) []string {
+	for i, ancestor := range ancestors {
+		if ancestor == dir {
+			cycle := make([]string, 0, len(ancestors)-i+1)
+			cycle = append(cycle, ancestors[i:]...)
+			return append(cycle, dir)
+		}
+	}
+	return nil
+}
+
+func childModulePath(parent, name string) string {
+	if parent == "" {
+		return "module." + name
+	}
+	return parent + ".module." + name
+}
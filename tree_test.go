@@ -0,0 +1,99 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadModuleTree(t *testing.T) {
+	tree, diags := LoadModuleTreeFromFilesystem(WrapFS(os.DirFS(".")), "testdata/module-tree")
+	if tree == nil {
+		t.Fatalf("result object is nil; want a real object")
+	}
+
+	var gotPaths []string
+	for path := range tree.Modules {
+		gotPaths = append(gotPaths, path)
+	}
+	sort.Strings(gotPaths)
+	wantPaths := []string{
+		"",
+		"module.consul",
+		"module.dns",
+		"module.network",
+		"module.network.module.subnets",
+	}
+	if diff := cmp.Diff(wantPaths, gotPaths); diff != "" {
+		t.Errorf("wrong module paths\n%s", diff)
+	}
+
+	consul := tree.Modules["module.consul"]
+	if got, want := consul.Module.Path, "testdata/module-tree/.terraform/modules/consul"; got != want {
+		t.Errorf("wrong directory for consul module\ngot:  %s\nwant: %s", got, want)
+	}
+
+	subnets := tree.Modules["module.network.module.subnets"]
+	if got, want := subnets.Module.Path, "testdata/module-tree/modules/subnets"; got != want {
+		t.Errorf("wrong directory for subnets module\ngot:  %s\nwant: %s", got, want)
+	}
+	if subnets.Parent != tree.Root.Children["network"] {
+		t.Errorf("subnets module has wrong parent")
+	}
+	if subnets.Call != tree.Modules["module.network"].Module.ModuleCalls["subnets"] {
+		t.Errorf("subnets module has wrong call")
+	}
+
+	type diagSummary struct {
+		Summary string
+		Pos     SourcePos
+	}
+	var gotDiags []diagSummary
+	for _, diag := range diags {
+		gotDiags = append(gotDiags, diagSummary{diag.Summary, *diag.Pos})
+	}
+	wantDiags := []diagSummary{
+		{
+			Summary: "Module not found",
+			Pos:     SourcePos{Filename: "testdata/module-tree/main.tf", Line: 7},
+		},
+		{
+			Summary: "Module call cycle",
+			Pos:     SourcePos{Filename: "testdata/module-tree/modules/subnets/main.tf", Line: 1},
+		},
+		{
+			Summary: "Module not installed",
+			Pos:     SourcePos{Filename: "testdata/module-tree/main.tf", Line: 16},
+		},
+	}
+	if diff := cmp.Diff(wantDiags, gotDiags); diff != "" {
+		t.Errorf("wrong diagnostics\n%s", diff)
+	}
+}
+
+func TestSameModuleSource(t *testing.T) {
+	tests := []struct {
+		Recorded, Configured string
+		Want                 bool
+	}{
+		{"registry.terraform.io/hashicorp/consul/aws", "hashicorp/consul/aws", true},
+		{"Registry.Terraform.io/hashicorp/consul/aws", "hashicorp/consul/aws", true},
+		{"registry.terraform.io/hashicorp/consul/aws", "hashicorp/consul/google", false},
+		{"example.com/hashicorp/consul/aws", "hashicorp/consul/aws", false},
+		{"git::https://github.com/hashicorp/example.git", "github.com/hashicorp/example", true},
+		{"git::https://github.com/hashicorp/example.git?ref=v1.0.0", "github.com/hashicorp/example?ref=v1.0.0", true},
+		{"git::https://github.com/hashicorp/example.git?ref=v1.0.0", "github.com/hashicorp/example?ref=v2.0.0", false},
+		{"./modules/network", "./modules/network", true},
+		{"./modules/network", "./modules/other", false},
+	}
+	for _, test := range tests {
+		if got := sameModuleSource(test.Recorded, test.Configured); got != test.Want {
+			t.Errorf("wrong result for %q and %q: got %t, want %t", test.Recorded, test.Configured, got, test.Want)
+		}
+	}
+}
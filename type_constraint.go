@@ -0,0 +1,538 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// TypeConstraint is the parsed form of the type constraint of an input
+// variable, like "map(object({ name = string }))".
+//
+// The structure of a TypeConstraint mirrors the structure of the type
+// expression, so that callers can walk the tree of types without dealing
+// with cty directly. Object types also record which of their attributes are
+// optional, and the default values of those attributes, which cty.Type
+// alone can't represent.
+type TypeConstraint struct {
+	// Type is the cty type described by the constraint. cty.DynamicPseudoType
+	// represents the "any" keyword. Optional object attributes are included
+	// in object types as if they were required.
+	Type cty.Type
+
+	// ElementType is the type of the elements of a list, set or map type.
+	ElementType *TypeConstraint
+
+	// ElementTypes are the types of the elements of a tuple type.
+	ElementTypes []*TypeConstraint
+
+	// Attributes are the attributes of an object type.
+	Attributes map[string]*TypeConstraintAttribute
+}
+
+// TypeConstraintAttribute is a single attribute of an object type within
+// a TypeConstraint.
+type TypeConstraintAttribute struct {
+	Type *TypeConstraint `json:"type"`
+
+	// Optional is true if the attribute was declared using the optional
+	// modifier, in which case callers may omit it.
+	Optional bool `json:"optional,omitempty"`
+
+	// DefaultValue is the default value given as the second argument to the
+	// optional modifier, or cty.NilVal if there is none. Default is an
+	// approximate representation of the same value in the native Go type
+	// system; see Variable.Default for more information.
+	DefaultValue cty.Value   `json:"-"`
+	Default      interface{} `json:"default,omitempty"`
+}
+
+// Kind returns the name of the kind of type described by the constraint,
+// which is one of "string", "number", "bool", "any", "list", "set", "map",
+// "tuple" or "object".
+func (tc *TypeConstraint) Kind() string {
+	ty := tc.Type
+	switch {
+	case ty == cty.DynamicPseudoType:
+		return "any"
+	case ty.IsPrimitiveType():
+		return ty.FriendlyName()
+	case ty.IsListType():
+		return "list"
+	case ty.IsSetType():
+		return "set"
+	case ty.IsMapType():
+		return "map"
+	case ty.IsTupleType():
+		return "tuple"
+	case ty.IsObjectType():
+		return "object"
+	default:
+		// should never happen
+		return ""
+	}
+}
+
+// String returns the type constraint in the native syntax of type
+// expressions, like "map(object({name = string}))".
+func (tc *TypeConstraint) String() string {
+	switch kind := tc.Kind(); kind {
+	case "list", "set", "map":
+		return fmt.Sprintf("%s(%s)", kind, tc.ElementType.String())
+	case "tuple":
+		elems := make([]string, len(tc.ElementTypes))
+		for i, elem := range tc.ElementTypes {
+			elems[i] = elem.String()
+		}
+		return fmt.Sprintf("tuple([%s])", strings.Join(elems, ", "))
+	case "object":
+		names := sortedKeys(tc.Attributes)
+		attrs := make([]string, len(names))
+		for i, name := range names {
+			attr := tc.Attributes[name]
+			switch {
+			case !attr.DefaultValue.IsNull():
+				def, _ := json.Marshal(attr.Default)
+				attrs[i] = fmt.Sprintf("%s = optional(%s, %s)", name, attr.Type.String(), def)
+			case attr.Optional:
+				attrs[i] = fmt.Sprintf("%s = optional(%s)", name, attr.Type.String())
+			default:
+				attrs[i] = fmt.Sprintf("%s = %s", name, attr.Type.String())
+			}
+		}
+		return fmt.Sprintf("object({%s})", strings.Join(attrs, ", "))
+	default:
+		return kind
+	}
+}
+
+// MarshalJSON implements encoding/json.Marshaler.
+func (tc *TypeConstraint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind         string                              `json:"kind"`
+		ElementType  *TypeConstraint                     `json:"element_type,omitempty"`
+		ElementTypes []*TypeConstraint                   `json:"element_types,omitempty"`
+		Attributes   map[string]*TypeConstraintAttribute `json:"attributes,omitempty"`
+	}{
+		Kind:         tc.Kind(),
+		ElementType:  tc.ElementType,
+		ElementTypes: tc.ElementTypes,
+		Attributes:   tc.Attributes,
+	})
+}
+
+// decodeVariableType decodes the type argument of a variable block.
+//
+// Older versions of Terraform expected the type to be given as a quoted
+// string containing a keyword, so we accept those forms too. As in
+// Terraform, the bare keywords "list" and "map" also keep their legacy
+// meaning of a list or map of any element type.
+//
+// In JSON the type is always a string, which may contain either a legacy
+// keyword or a type expression, optionally wrapped in an interpolation
+// sequence like "${list(number)}".
+func decodeVariableType(expr hcl.Expression, file *hcl.File, opts *decodeOptions) (*TypeConstraint, hcl.Diagnostics) {
+	if tmpl, ok := expr.(*hclsyntax.TemplateExpr); ok && tmpl.IsStringLiteral() {
+		val, _ := tmpl.Value(nil)
+		tc, err := legacyTypeConstraint(val.AsString())
+		if err != nil {
+			return nil, hcl.Diagnostics{
+				opts.diagnostic("invalid-legacy-type-hint", &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid legacy variable type hint",
+					Detail:   err.Error(),
+					Subject:  expr.Range().Ptr(),
+				}),
+			}
+		}
+		return tc, legacyTypeHint(expr, tc, opts)
+	}
+
+	switch keyword := hcl.ExprAsKeyword(expr); keyword {
+	case "list", "map":
+		tc, _ := legacyTypeConstraint(keyword)
+		return tc, legacyTypeHint(expr, tc, opts)
+	}
+
+	if _, native := expr.(hclsyntax.Expression); !native {
+		inner, diags := jsonTypeExpression(expr, file)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		if inner != nil {
+			return decodeVariableType(inner, file, opts)
+		}
+	}
+	return decodeTypeConstraint(expr, opts)
+}
+
+// legacyTypeHint returns a hint to replace the legacy type syntax of the
+// given expression with the type expression for the given constraint.
+func legacyTypeHint(expr hcl.Expression, tc *TypeConstraint, opts *decodeOptions) hcl.Diagnostics {
+	return hcl.Diagnostics{
+		opts.hint("legacy-type-syntax", &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Legacy type constraint syntax",
+			Detail:   fmt.Sprintf("This type constraint uses the syntax of Terraform versions before v0.12. Write it as %s instead.", tc.String()),
+			Subject:  expr.Range().Ptr(),
+		}),
+	}
+}
+
+// jsonTypeExpression returns the type expression inside the interpolation
+// sequence of the given JSON string expression, or nil if it doesn't
+// consist of a single interpolation sequence.
+func jsonTypeExpression(expr hcl.Expression, file *hcl.File) (hclsyntax.Expression, hcl.Diagnostics) {
+	rng := expr.Range()
+	var src string
+	if err := json.Unmarshal(rng.SliceBytes(file.Bytes), &src); err != nil {
+		return nil, nil
+	}
+	if !strings.HasPrefix(src, "${") || !strings.HasSuffix(src, "}") {
+		return nil, nil
+	}
+	// The positions of the parsed expression are only approximate, because
+	// they don't account for any escape sequences in the JSON string.
+	start := rng.Start
+	start.Column += 3
+	start.Byte += 3
+	return hclsyntax.ParseExpression([]byte(src[2:len(src)-1]), rng.Filename, start)
+}
+
+// legacyTypeConstraint returns the type constraint corresponding to the
+// given keyword from the legacy variable type syntax, where only "string",
+// "list" and "map" were allowed.
+func legacyTypeConstraint(keyword string) (*TypeConstraint, error) {
+	switch keyword {
+	case "string":
+		return &TypeConstraint{Type: cty.String}, nil
+	case "list":
+		return &TypeConstraint{
+			Type:        cty.List(cty.DynamicPseudoType),
+			ElementType: &TypeConstraint{Type: cty.DynamicPseudoType},
+		}, nil
+	case "map":
+		return &TypeConstraint{
+			Type:        cty.Map(cty.DynamicPseudoType),
+			ElementType: &TypeConstraint{Type: cty.DynamicPseudoType},
+		}, nil
+	default:
+		return nil, fmt.Errorf("The legacy variable type hint %q is not supported; it must be \"string\", \"list\" or \"map\".", keyword)
+	}
+}
+
+// decodeTypeConstraint decodes a type expression in the native syntax, like
+// "list(string)".
+func decodeTypeConstraint(expr hcl.Expression, opts *decodeOptions) (*TypeConstraint, hcl.Diagnostics) {
+	switch keyword := hcl.ExprAsKeyword(expr); keyword {
+	case "":
+		// Not a keyword, so must be a type constructor call below.
+	case "string":
+		return &TypeConstraint{Type: cty.String}, nil
+	case "number":
+		return &TypeConstraint{Type: cty.Number}, nil
+	case "bool":
+		return &TypeConstraint{Type: cty.Bool}, nil
+	case "any":
+		return &TypeConstraint{Type: cty.DynamicPseudoType}, nil
+	case "list", "set", "map":
+		return nil, typeConstraintError(expr, fmt.Sprintf("The %s type constructor requires one argument specifying the element type.", keyword), opts)
+	case "tuple":
+		return nil, typeConstraintError(expr, "The tuple type constructor requires one argument specifying the element types as a list.", opts)
+	case "object":
+		return nil, typeConstraintError(expr, "The object type constructor requires one argument specifying the attribute types as a map.", opts)
+	default:
+		return nil, typeConstraintError(expr, fmt.Sprintf("The keyword %q is not a valid type specification.", keyword), opts)
+	}
+
+	call, diags := hcl.ExprCall(expr)
+	if diags.HasErrors() {
+		return nil, typeConstraintError(expr, "A type specification is either a primitive type keyword (bool, number, string), the keyword \"any\", or a complex type constructor call, like list(string).", opts)
+	}
+
+	switch call.Name {
+	case "list", "set", "map":
+		if len(call.Arguments) != 1 {
+			return nil, typeConstraintError(expr, fmt.Sprintf("The %s type constructor requires one argument specifying the element type.", call.Name), opts)
+		}
+		elem, diags := decodeTypeConstraint(call.Arguments[0], opts)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		tc := &TypeConstraint{ElementType: elem}
+		switch call.Name {
+		case "list":
+			tc.Type = cty.List(elem.Type)
+		case "set":
+			tc.Type = cty.Set(elem.Type)
+		case "map":
+			tc.Type = cty.Map(elem.Type)
+		}
+		return tc, nil
+
+	case "tuple":
+		if len(call.Arguments) != 1 {
+			return nil, typeConstraintError(expr, "The tuple type constructor requires one argument specifying the element types as a list.", opts)
+		}
+		elemExprs, listDiags := hcl.ExprList(call.Arguments[0])
+		if listDiags.HasErrors() {
+			return nil, typeConstraintError(call.Arguments[0], "Tuple type constructor requires a list of element types.", opts)
+		}
+		tc := &TypeConstraint{}
+		elemTypes := make([]cty.Type, len(elemExprs))
+		for i, elemExpr := range elemExprs {
+			elem, elemDiags := decodeTypeConstraint(elemExpr, opts)
+			diags = append(diags, elemDiags...)
+			if elemDiags.HasErrors() {
+				continue
+			}
+			tc.ElementTypes = append(tc.ElementTypes, elem)
+			elemTypes[i] = elem.Type
+		}
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		tc.Type = cty.Tuple(elemTypes)
+		return tc, nil
+
+	case "object":
+		if len(call.Arguments) != 1 {
+			return nil, typeConstraintError(expr, "The object type constructor requires one argument specifying the attribute types as a map.", opts)
+		}
+		pairs, mapDiags := hcl.ExprMap(call.Arguments[0])
+		if mapDiags.HasErrors() {
+			return nil, typeConstraintError(call.Arguments[0], "Object type constructor requires a map whose keys are attribute names and whose values are the corresponding attribute types.", opts)
+		}
+		tc := &TypeConstraint{
+			Attributes: make(map[string]*TypeConstraintAttribute, len(pairs)),
+		}
+		attrTypes := make(map[string]cty.Type, len(pairs))
+		for _, pair := range pairs {
+			name := hcl.ExprAsKeyword(pair.Key)
+			if name == "" {
+				diags = append(diags, typeConstraintError(pair.Key, "Object constructor map keys must be attribute names.", opts)...)
+				continue
+			}
+			attr, attrDiags := decodeTypeConstraintAttribute(pair.Value, opts)
+			diags = append(diags, attrDiags...)
+			if attrDiags.HasErrors() {
+				continue
+			}
+			tc.Attributes[name] = attr
+			attrTypes[name] = attr.Type.Type
+		}
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		tc.Type = cty.Object(attrTypes)
+		return tc, nil
+
+	case "optional":
+		// Object attributes are handled by decodeTypeConstraintAttribute,
+		// so this is a modifier in some other position.
+		return nil, typeConstraintError(expr, "Keyword \"optional\" is valid only as a modifier for object type attributes.", opts)
+
+	default:
+		return nil, typeConstraintError(expr, fmt.Sprintf("Keyword %q is not a valid type constructor.", call.Name), opts)
+	}
+}
+
+// decodeTypeConstraintAttribute decodes the type of a single attribute in
+// an object type constructor, which may use the optional modifier.
+func decodeTypeConstraintAttribute(expr hcl.Expression, opts *decodeOptions) (*TypeConstraintAttribute, hcl.Diagnostics) {
+	call, diags := hcl.ExprCall(expr)
+	if diags.HasErrors() || call.Name != "optional" {
+		ty, diags := decodeTypeConstraint(expr, opts)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		return &TypeConstraintAttribute{Type: ty}, nil
+	}
+
+	if len(call.Arguments) < 1 || len(call.Arguments) > 2 {
+		return nil, typeConstraintError(expr, "The optional modifier requires the attribute type as its first argument and an optional default value as its second argument.", opts)
+	}
+	ty, diags := decodeTypeConstraint(call.Arguments[0], opts)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	attr := &TypeConstraintAttribute{
+		Type:     ty,
+		Optional: true,
+	}
+	if len(call.Arguments) == 2 {
+		val, valDiags := call.Arguments[1].Value(nil)
+		if valDiags.HasErrors() || !val.IsWhollyKnown() {
+			return nil, typeConstraintError(call.Arguments[1], "The default value for an optional attribute must be a constant value.", opts)
+		}
+		if !val.IsNull() {
+			attr.DefaultValue = val
+			attr.Default = ctyValueToGo(val)
+		}
+	}
+	return attr, nil
+}
+
+func typeConstraintError(expr hcl.Expression, detail string, opts *decodeOptions) hcl.Diagnostics {
+	return hcl.Diagnostics{
+		opts.diagnostic("invalid-type-constraint", &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid type specification",
+			Detail:   detail,
+			Subject:  expr.Range().Ptr(),
+		}),
+	}
+}
+
+// Convert converts the given value to the type described by the constraint,
+// first inserting the default values of any optional object attributes that
+// the value omits, as Terraform does for input variable values.
+func (tc *TypeConstraint) Convert(val cty.Value) (cty.Value, error) {
+	val, err := tc.applyDefaults(val, nil)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return convert.Convert(val, tc.Type)
+}
+
+// applyDefaults returns a copy of the given value with the default values
+// of optional object attributes inserted wherever the value omits them,
+// recursively through any collection and structural types.
+//
+// Collection values are returned as tuple or object values, so that their
+// elements may differ in type until the final conversion unifies them.
+func (tc *TypeConstraint) applyDefaults(val cty.Value, path cty.Path) (cty.Value, error) {
+	if val.IsNull() || !val.IsKnown() {
+		return val, nil
+	}
+	ty := val.Type()
+
+	switch tc.Kind() {
+	case "list", "set":
+		if !ty.IsListType() && !ty.IsSetType() && !ty.IsTupleType() {
+			return val, nil
+		}
+		var elems []cty.Value
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			elem, err := tc.ElementType.applyDefaults(elem, path.Index(key))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems = append(elems, elem)
+		}
+		if len(elems) == 0 {
+			return val, nil
+		}
+		return cty.TupleVal(elems), nil
+
+	case "map":
+		if !ty.IsMapType() && !ty.IsObjectType() {
+			return val, nil
+		}
+		attrs := make(map[string]cty.Value)
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			elem, err := tc.ElementType.applyDefaults(elem, path.Index(key))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attrs[key.AsString()] = elem
+		}
+		if len(attrs) == 0 {
+			return val, nil
+		}
+		return cty.ObjectVal(attrs), nil
+
+	case "tuple":
+		if (!ty.IsTupleType() && !ty.IsListType()) || val.LengthInt() != len(tc.ElementTypes) {
+			return val, nil
+		}
+		var elems []cty.Value
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			i, _ := key.AsBigFloat().Int64()
+			elem, err := tc.ElementTypes[i].applyDefaults(elem, path.Index(key))
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems = append(elems, elem)
+		}
+		if len(elems) == 0 {
+			return val, nil
+		}
+		return cty.TupleVal(elems), nil
+
+	case "object":
+		if !ty.IsObjectType() && !ty.IsMapType() {
+			return val, nil
+		}
+		given := make(map[string]cty.Value)
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			given[key.AsString()] = elem
+		}
+		attrs := make(map[string]cty.Value, len(tc.Attributes))
+		for _, name := range sortedKeys(tc.Attributes) {
+			attr := tc.Attributes[name]
+			attrPath := path.GetAttr(name)
+			elem, exists := given[name]
+			if !exists || elem.IsNull() {
+				switch {
+				case !attr.DefaultValue.IsNull():
+					elem = attr.DefaultValue
+				case attr.Optional || exists:
+					attrs[name] = cty.NullVal(attr.Type.Type)
+					continue
+				default:
+					return cty.NilVal, path.NewErrorf("attribute %q is required", name)
+				}
+			}
+			elem, err := attr.Type.applyDefaults(elem, attrPath)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attrs[name] = elem
+		}
+		if len(attrs) == 0 {
+			return cty.EmptyObjectVal, nil
+		}
+		return cty.ObjectVal(attrs), nil
+
+	default:
+		return val, nil
+	}
+}
+
+// formatTypeConversionError returns a message describing the given error
+// from TypeConstraint.Convert, including the path to the offending part of
+// the value if there is one.
+func formatTypeConversionError(err error) string {
+	pathErr, ok := err.(cty.PathError)
+	if !ok || len(pathErr.Path) == 0 {
+		return err.Error()
+	}
+
+	var b strings.Builder
+	for _, step := range pathErr.Path {
+		switch step := step.(type) {
+		case cty.GetAttrStep:
+			fmt.Fprintf(&b, ".%s", step.Name)
+		case cty.IndexStep:
+			if step.Key.Type() == cty.String {
+				fmt.Fprintf(&b, "[%q]", step.Key.AsString())
+			} else if step.Key.Type() == cty.Number {
+				fmt.Fprintf(&b, "[%s]", step.Key.AsBigFloat().Text('f', -1))
+			}
+		}
+	}
+	return fmt.Sprintf("%s: %s", strings.TrimPrefix(b.String(), "."), pathErr.Error())
+}
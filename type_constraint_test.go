@@ -0,0 +1,139 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDecodeTypeConstraint(t *testing.T) {
+	tests := map[string]struct {
+		want     string
+		wantType cty.Type
+		wantErr  string
+	}{
+		`string`: {
+			want:     `string`,
+			wantType: cty.String,
+		},
+		`any`: {
+			want:     `any`,
+			wantType: cty.DynamicPseudoType,
+		},
+		`list(map(number))`: {
+			want:     `list(map(number))`,
+			wantType: cty.List(cty.Map(cty.Number)),
+		},
+		`tuple([string, bool])`: {
+			want:     `tuple([string, bool])`,
+			wantType: cty.Tuple([]cty.Type{cty.String, cty.Bool}),
+		},
+		`object({ b = optional(number, 2), a = string, c = optional(set(string)) })`: {
+			want: `object({a = string, b = optional(number, 2), c = optional(set(string))})`,
+			wantType: cty.Object(map[string]cty.Type{
+				"a": cty.String,
+				"b": cty.Number,
+				"c": cty.Set(cty.String),
+			}),
+		},
+		`map`: {
+			wantErr: `The map type constructor requires one argument specifying the element type.`,
+		},
+		`list(string, number)`: {
+			wantErr: `The list type constructor requires one argument specifying the element type.`,
+		},
+		`optional(string)`: {
+			wantErr: `Keyword "optional" is valid only as a modifier for object type attributes.`,
+		},
+		`object({ a = optional(string, var.default) })`: {
+			wantErr: `The default value for an optional attribute must be a constant value.`,
+		},
+		`string_or_number`: {
+			wantErr: `The keyword "string_or_number" is not a valid type specification.`,
+		},
+	}
+
+	for src, test := range tests {
+		t.Run(src, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(src), "test.tf", hcl.InitialPos)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", diags.Error())
+			}
+			got, diags := decodeTypeConstraint(expr, newDecodeOptions(LoadOptions{}))
+			if test.wantErr != "" {
+				if !diags.HasErrors() {
+					t.Fatalf("unexpected success; want error: %s", test.wantErr)
+				}
+				if got := diags[0].Detail; got != test.wantErr {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, test.wantErr)
+				}
+				return
+			}
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Error())
+			}
+			if got.String() != test.want {
+				t.Errorf("wrong result\ngot:  %s\nwant: %s", got.String(), test.want)
+			}
+			if !got.Type.Equals(test.wantType) {
+				t.Errorf("wrong type\ngot:  %#v\nwant: %#v", got.Type, test.wantType)
+			}
+		})
+	}
+}
+
+func TestDecodeVariableType(t *testing.T) {
+	tests := map[string]struct {
+		filename string
+		src      string
+		want     string
+		wantHint bool
+	}{
+		"bare list":          {"test.tf", `type = list`, `list(any)`, true},
+		"bare map":           {"test.tf", `type = map`, `map(any)`, true},
+		"quoted list":        {"test.tf", `type = "list"`, `list(any)`, true},
+		"type expression":    {"test.tf", `type = set(string)`, `set(string)`, false},
+		"JSON list":          {"test.tf.json", `{"type": "list"}`, `list(any)`, true},
+		"JSON map":           {"test.tf.json", `{"type": "map"}`, `map(any)`, true},
+		"JSON expression":    {"test.tf.json", `{"type": "map(number)"}`, `map(number)`, false},
+		"JSON interpolation": {"test.tf.json", `{"type": "${list(number)}"}`, `list(number)`, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parser := hclparse.NewParser()
+			var file *hcl.File
+			var diags hcl.Diagnostics
+			if test.filename == "test.tf.json" {
+				file, diags = parser.ParseJSON([]byte(test.src), test.filename)
+			} else {
+				file, diags = parser.ParseHCL([]byte(test.src), test.filename)
+			}
+			if diags.HasErrors() {
+				t.Fatalf("unexpected parse errors: %s", diags.Error())
+			}
+			attrs, diags := file.Body.JustAttributes()
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Error())
+			}
+			opts := newDecodeOptions(LoadOptions{})
+			got, diags := decodeVariableType(attrs["type"].Expr, file, opts)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Error())
+			}
+			gotHint := len(diags) == 1 && diagnosticsHCL(diags, opts)[0].Severity == DiagHint
+			if gotHint != test.wantHint || len(diags) > 1 {
+				t.Errorf("wrong diagnostics %#v; want hint %t", diags, test.wantHint)
+			}
+			if got.String() != test.want {
+				t.Errorf("wrong result\ngot:  %s\nwant: %s", got.String(), test.want)
+			}
+		})
+	}
+}
@@ -3,19 +3,181 @@
 
 package terraparse
 
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/zclconf/go-cty/cty"
+)
+
 // Variable represents a single variable from a Terraform module.
 type Variable struct {
 	Name        string `json:"name"`
 	Type        string `json:"type,omitempty"`
 	Description string `json:"description,omitempty"`
 
+	// TypeConstraint is the parsed form of Type, or nil if the variable has
+	// no type constraint or if its type constraint is invalid.
+	TypeConstraint *TypeConstraint `json:"type_constraint,omitempty"`
+
 	// Default is an approximate representation of the default value in
 	// the native Go type system. The conversion from the value given in
 	// configuration may be slightly lossy. Only values that can be
 	// serialized by json.Marshal will be included here.
-	Default   interface{} `json:"default"`
-	Required  bool        `json:"required"`
-	Sensitive bool        `json:"sensitive,omitempty"`
+	Default interface{} `json:"default"`
+
+	// DefaultValue is the default value as given in configuration, or
+	// cty.NilVal if the variable has no default.
+	DefaultValue cty.Value `json:"-"`
+
+	// ConvertedDefault is the default value after conversion to the
+	// variable's type constraint, including the default values of any
+	// optional object attributes. It is populated only if the variable has
+	// both a type constraint and a non-null default that conforms to it.
+	// ConvertedDefaultValue is the same value as a cty.Value.
+	ConvertedDefault      interface{} `json:"converted_default,omitempty"`
+	ConvertedDefaultValue cty.Value   `json:"-"`
+
+	Required  bool `json:"required"`
+	Sensitive bool `json:"sensitive,omitempty"`
+
+	// Nullable records the value of the "nullable" argument, or is nil if
+	// the argument is not set, in which case the variable is nullable.
+	Nullable  *bool `json:"nullable,omitempty"`
+	Ephemeral bool  `json:"ephemeral,omitempty"`
+
+	// Validations are the custom validation rules for the variable's value.
+	Validations []*CheckRule `json:"validations,omitempty"`
 
 	Pos SourcePos `json:"pos"`
+	Provenance
+}
+
+// convertDefault converts the default value of the variable to its type
+// constraint, populating ConvertedDefault and ConvertedDefaultValue, and
+// returns an error diagnostic if the default does not conform to the type.
+func (v *Variable) convertDefault() Diagnostics {
+	if v.TypeConstraint == nil || v.DefaultValue.IsNull() {
+		return nil
+	}
+
+	val, err := v.TypeConstraint.Convert(v.DefaultValue)
+	if err != nil {
+		pos := v.Pos
+		return Diagnostics{
+			{
+				Severity: DiagError,
+				Code:     "invalid-variable-default",
+				Summary:  "Invalid default value for variable",
+				Detail:   fmt.Sprintf("The default value of variable %q is not compatible with the variable's type constraint %s: %s.", v.Name, v.TypeConstraint.String(), formatTypeConversionError(err)),
+				Pos:      &pos,
+			},
+		}
+	}
+
+	v.ConvertedDefaultValue = val
+	if val.IsWhollyKnown() {
+		v.ConvertedDefault = ctyValueToGo(val)
+	}
+	return nil
+}
+
+// IsNullable returns true if the variable may be set to null, which is the
+// case unless its "nullable" argument is set to false.
+func (v *Variable) IsNullable() bool {
+	return v.Nullable == nil || *v.Nullable
+}
+
+// decodeVariableBlock decodes a "variable" block from the given file.
+func decodeVariableBlock(block *hcl.Block, file *hcl.File, opts *decodeOptions) (*Variable, hcl.Diagnostics) {
+	content, _, diags := block.Body.PartialContent(variableSchema)
+
+	name := block.Labels[0]
+	v := &Variable{
+		Name:       name,
+		Pos:        sourcePosHCL(block.DefRange, opts),
+		Provenance: newProvenance(block.DefRange, content.Attributes, nil, opts),
+	}
+
+	if attr, defined := content.Attributes["type"]; defined {
+		// We handle this particular attribute in a somewhat-tricky way:
+		// since Terraform may evolve its type expression syntax in
+		// future versions, we don't want to be overly-strict in how
+		// we handle it here, and so we'll instead just take the raw
+		// source provided by the user, using the source location
+		// information in the expression object.
+		//
+		// However, older versions of Terraform expected the type
+		// to be a string containing a keyword, so we'll need to
+		// handle that as a special case first for backward compatibility.
+
+		var typeExpr string
+
+		var typeExprAsStr string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &typeExprAsStr)
+		if !valDiags.HasErrors() {
+			typeExpr = typeExprAsStr
+		} else {
+			rng := attr.Expr.Range()
+			typeExpr = string(rng.SliceBytes(file.Bytes))
+		}
+
+		v.Type = typeExpr
+
+		tc, typeDiags := decodeVariableType(attr.Expr, file, opts)
+		diags = append(diags, typeDiags...)
+		v.TypeConstraint = tc
+	}
+
+	if attr, defined := content.Attributes["description"]; defined {
+		var description string
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &description)
+		diags = append(diags, valDiags...)
+		v.Description = description
+	}
+
+	if attr, defined := content.Attributes["default"]; defined {
+		// To avoid the caller needing to deal with cty here, we'll
+		// use its JSON encoding to convert into an
+		// approximately-equivalent plain Go interface{} value
+		// to return.
+		val, valDiags := attr.Expr.Value(nil)
+		diags = append(diags, valDiags...)
+		if val.IsWhollyKnown() { // should only be false if there are errors in the input
+			v.Default = ctyValueToGo(val)
+			v.DefaultValue = val
+		}
+	} else {
+		v.Required = true
+	}
+
+	if attr, defined := content.Attributes["sensitive"]; defined {
+		var sensitive bool
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &sensitive)
+		diags = append(diags, valDiags...)
+		v.Sensitive = sensitive
+	}
+
+	if attr, defined := content.Attributes["nullable"]; defined {
+		var nullable bool
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &nullable)
+		diags = append(diags, valDiags...)
+		v.Nullable = &nullable
+	}
+
+	if attr, defined := content.Attributes["ephemeral"]; defined {
+		var ephemeral bool
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &ephemeral)
+		diags = append(diags, valDiags...)
+		v.Ephemeral = ephemeral
+	}
+
+	for _, block := range content.Blocks {
+		cr, crDiags := decodeCheckRule(block, file, opts)
+		diags = append(diags, crDiags...)
+		v.Validations = append(v.Validations, cr)
+	}
+
+	return v, diags
 }
@@ -0,0 +1,203 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// VariableValue is a value for an input variable, as given in a variable
+// definitions (.tfvars) file.
+type VariableValue struct {
+	Name string `json:"name"`
+
+	// Value is the value as given in the file, before conversion to the
+	// variable's type constraint.
+	Value cty.Value `json:"-"`
+
+	Pos SourcePos `json:"pos"`
+}
+
+// VariableValues is a map of VariableValue objects by variable name.
+type VariableValues map[string]*VariableValue
+
+// LoadVariableValues reads the variable definitions files at the given
+// paths in the given FS. Files with a ".json" suffix are parsed as JSON,
+// and all others as native syntax.
+//
+// If more than one file sets the same variable then the value from the
+// file that appears latest in the paths takes precedence, as in Terraform.
+func LoadVariableValues(fs FS, paths ...string) (VariableValues, Diagnostics) {
+	return LoadVariableValuesWithOptions(fs, LoadOptions{}, paths...)
+}
+
+// LoadVariableValuesWithOptions is like LoadVariableValues, customized by
+// the given options.
+func LoadVariableValuesWithOptions(fs FS, loadOpts LoadOptions, paths ...string) (VariableValues, Diagnostics) {
+	opts := newDecodeOptions(loadOpts)
+	var diags hcl.Diagnostics
+	values := make(VariableValues)
+	parser := hclparse.NewParser()
+
+	for _, filename := range paths {
+		src, err := fs.ReadFile(filename)
+		if err != nil {
+			diags = append(diags, opts.diagnostic("read-variable-file-failed", &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Failed to read variable definitions file",
+				Detail:   fmt.Sprintf("The variable definitions file %q could not be read.", filename),
+			}))
+			continue
+		}
+
+		var file *hcl.File
+		var fileDiags hcl.Diagnostics
+		if strings.HasSuffix(filename, ".json") {
+			file, fileDiags = parser.ParseJSON(src, filename)
+		} else {
+			file, fileDiags = parser.ParseHCL(src, filename)
+		}
+		diags = append(diags, fileDiags...)
+		if file == nil {
+			continue
+		}
+
+		attrs, attrDiags := file.Body.JustAttributes()
+		diags = append(diags, attrDiags...)
+		for name, attr := range attrs {
+			// Variable definitions files may contain only constant values,
+			// so evaluation without a context will report any references
+			// or function calls as errors.
+			val, valDiags := attr.Expr.Value(nil)
+			diags = append(diags, valDiags...)
+			if valDiags.HasErrors() {
+				continue
+			}
+			values[name] = &VariableValue{
+				Name:  name,
+				Value: val,
+				Pos:   sourcePosHCL(attr.NameRange, opts),
+			}
+		}
+	}
+
+	return values, diagnosticsHCL(diags, opts)
+}
+
+// AutoVariableFiles returns the paths of the variable definitions files in
+// the given directory that Terraform loads automatically, in the order that
+// Terraform loads them: "terraform.tfvars", then "terraform.tfvars.json",
+// then any files with ".auto.tfvars" or ".auto.tfvars.json" suffixes in
+// lexical order.
+func AutoVariableFiles(fs FS, dir string) ([]string, Diagnostics) {
+	infos, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil, diagnosticsErrorf("read-directory-failed", "Failed to read directory %s: %s", dir, err)
+	}
+
+	var defaults, autos []string
+	for _, info := range infos {
+		if info.IsDir() || isIgnoredFile(info.Name()) {
+			continue
+		}
+		name := info.Name()
+		switch {
+		case name == "terraform.tfvars" || name == "terraform.tfvars.json":
+			defaults = append(defaults, name)
+		case strings.HasSuffix(name, ".auto.tfvars") || strings.HasSuffix(name, ".auto.tfvars.json"):
+			autos = append(autos, name)
+		}
+	}
+	// "terraform.tfvars" sorts before "terraform.tfvars.json", as required.
+	sort.Strings(defaults)
+	sort.Strings(autos)
+
+	var paths []string
+	for _, name := range append(defaults, autos...) {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	return paths, nil
+}
+
+// ValidateInputs checks the given variable values against the input
+// variables declared in the module, returning diagnostics describing any
+// problems.
+//
+// In particular, it reports values for variables that the module does not
+// declare, required variables that have no value, null values for
+// variables that are not nullable, and values that do not conform to their
+// variable's type constraint. Custom validation rules are not evaluated.
+func (m *Module) ValidateInputs(values VariableValues) Diagnostics {
+	var diags Diagnostics
+
+	for _, name := range sortedKeys(values) {
+		value := values[name]
+		pos := value.Pos
+
+		v, declared := m.Variables[name]
+		if !declared {
+			diags = append(diags, Diagnostic{
+				Severity: DiagWarning,
+				Code:     "undeclared-variable-value",
+				Summary:  "Value for undeclared variable",
+				Detail:   fmt.Sprintf("The module does not declare a variable named %q but a value was found for it. To use this value, add a \"variable\" block to the module.", name),
+				Pos:      &pos,
+			})
+			continue
+		}
+
+		if value.Value.IsNull() {
+			if !v.IsNullable() && v.DefaultValue.IsNull() {
+				diags = append(diags, Diagnostic{
+					Severity: DiagError,
+					Code:     "null-required-variable",
+					Summary:  "Required variable not set",
+					Detail:   fmt.Sprintf("The variable %q is not nullable and has no default value, so it must not be set to null.", name),
+					Pos:      &pos,
+				})
+			}
+			continue
+		}
+
+		if v.TypeConstraint == nil {
+			continue
+		}
+		if _, err := v.TypeConstraint.Convert(value.Value); err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: DiagError,
+				Code:     "invalid-variable-value",
+				Summary:  "Invalid value for input variable",
+				Detail:   fmt.Sprintf("The given value is not suitable for variable %q, which has the type constraint %s: %s.", name, v.TypeConstraint.String(), formatTypeConversionError(err)),
+				Pos:      &pos,
+			})
+		}
+	}
+
+	for _, name := range sortedKeys(m.Variables) {
+		v := m.Variables[name]
+		if !v.Required {
+			continue
+		}
+		if _, given := values[name]; given {
+			continue
+		}
+		pos := v.Pos
+		diags = append(diags, Diagnostic{
+			Severity: DiagError,
+			Code:     "missing-variable-value",
+			Summary:  "No value for required variable",
+			Detail:   fmt.Sprintf("The input variable %q is not set, and has no default value.", name),
+			Pos:      &pos,
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,99 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestModuleValidateInputs(t *testing.T) {
+	fs := NewOsFs()
+	mod, diags := LoadModuleFromFilesystem(fs, "testdata/variable-values")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors loading module: %s", diags.Error())
+	}
+
+	paths, diags := AutoVariableFiles(fs, "testdata/variable-values")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors finding variable files: %s", diags.Error())
+	}
+	wantPaths := []string{
+		"testdata/variable-values/terraform.tfvars",
+		"testdata/variable-values/override.auto.tfvars.json",
+	}
+	if diff := cmp.Diff(wantPaths, paths); diff != "" {
+		t.Errorf("wrong automatic variable files\n%s", diff)
+	}
+
+	type diagSummary struct {
+		Severity DiagSeverity
+		Summary  string
+		Filename string
+		Line     int
+	}
+	tests := map[string]struct {
+		paths []string
+		want  []diagSummary
+	}{
+		"automatic files": {
+			paths: paths,
+			want: []diagSummary{
+				{DiagError, "Invalid value for input variable", "testdata/variable-values/override.auto.tfvars.json", 3},
+				{DiagWarning, "Value for undeclared variable", "testdata/variable-values/override.auto.tfvars.json", 6},
+			},
+		},
+		"invalid file": {
+			paths: []string{"testdata/variable-values/invalid.tfvars"},
+			want: []diagSummary{
+				{DiagError, "Required variable not set", "testdata/variable-values/invalid.tfvars", 1},
+				{DiagError, "Invalid value for input variable", "testdata/variable-values/invalid.tfvars", 3},
+			},
+		},
+		"no files": {
+			want: []diagSummary{
+				{DiagError, "No value for required variable", "testdata/variable-values/main.tf", 1},
+				{DiagError, "No value for required variable", "testdata/variable-values/main.tf", 11},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			values, diags := LoadVariableValues(fs, test.paths...)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors loading values: %s", diags.Error())
+			}
+			var got []diagSummary
+			for _, diag := range mod.ValidateInputs(values) {
+				got = append(got, diagSummary{diag.Severity, diag.Summary, diag.Pos.Filename, diag.Pos.Line})
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrong diagnostics\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadVariableValuesPrecedence(t *testing.T) {
+	values, diags := LoadVariableValues(NewOsFs(),
+		"testdata/variable-values/terraform.tfvars",
+		"testdata/variable-values/override.auto.tfvars.json",
+	)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Error())
+	}
+	got := values["instance_count"]
+	if got == nil {
+		t.Fatalf("no value for instance_count")
+	}
+	if !got.Value.RawEquals(cty.NumberIntVal(2)) {
+		t.Errorf("wrong value %#v; want 2 from the later file", got.Value)
+	}
+	if got.Pos.Filename != "testdata/variable-values/override.auto.tfvars.json" {
+		t.Errorf("wrong filename %s", got.Pos.Filename)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"fmt"
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+)
+
+// VersionConstraint is a single constraint on a version number, such as
+// the "~> 1.2" in a version constraint string like "~> 1.2, != 1.2.3".
+type VersionConstraint struct {
+	// Operator is one of "=", "!=", ">", ">=", "<", "<=" or "~>". It is "="
+	// if the constraint was written without an explicit operator.
+	Operator string `json:"operator"`
+	Version  string `json:"version"`
+}
+
+func (c VersionConstraint) String() string {
+	return c.Operator + " " + c.Version
+}
+
+// VersionConstraints is a set of version constraints that must all be
+// satisfied by a selected version.
+type VersionConstraints []VersionConstraint
+
+func (cs VersionConstraints) String() string {
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// versionConstraintOperators lists the operators accepted in a version
+// constraint, with each operator ahead of any shorter operator it begins
+// with so that the first prefix match is the right one.
+var versionConstraintOperators = []string{"~>", ">=", "<=", "!=", "=", ">", "<"}
+
+// ParseVersionConstraints parses a version constraint string, as used in
+// the "version" argument of module calls and provider requirements.
+//
+// Each comma-separated constraint is validated by go-version, so this
+// accepts exactly the constraint syntax that Terraform itself accepts.
+func ParseVersionConstraints(raw string) (VersionConstraints, error) {
+	parts := strings.Split(raw, ",")
+	ret := make(VersionConstraints, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if _, err := version.NewConstraint(part); err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q", part)
+		}
+		op := "="
+		for _, candidate := range versionConstraintOperators {
+			if strings.HasPrefix(part, candidate) {
+				op = candidate
+				part = strings.TrimSpace(part[len(candidate):])
+				break
+			}
+		}
+		ret = append(ret, VersionConstraint{
+			Operator: op,
+			Version:  part,
+		})
+	}
+	return ret, nil
+}
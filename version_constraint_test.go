@@ -0,0 +1,103 @@
+// Copyright (c) Josh Feierman (original copyright HashiCorp, Inc).
+// SPDX-License-Identifier: MPL-2.0
+
+package terraparse
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseVersionConstraints(t *testing.T) {
+	tests := map[string]struct {
+		want    VersionConstraints
+		wantErr string
+	}{
+		"1.2.3": {
+			want: VersionConstraints{{Operator: "=", Version: "1.2.3"}},
+		},
+		"= 1.2.3": {
+			want: VersionConstraints{{Operator: "=", Version: "1.2.3"}},
+		},
+		"1.2.3-beta.1": {
+			want: VersionConstraints{{Operator: "=", Version: "1.2.3-beta.1"}},
+		},
+		">= 1.0.0-rc1": {
+			want: VersionConstraints{{Operator: ">=", Version: "1.0.0-rc1"}},
+		},
+		"~> 1": {
+			want: VersionConstraints{{Operator: "~>", Version: "1"}},
+		},
+		"~> 1.2": {
+			want: VersionConstraints{{Operator: "~>", Version: "1.2"}},
+		},
+		"~> 1.2.3": {
+			want: VersionConstraints{{Operator: "~>", Version: "1.2.3"}},
+		},
+		">=1.0": {
+			want: VersionConstraints{{Operator: ">=", Version: "1.0"}},
+		},
+		"  >=   1.0  ": {
+			want: VersionConstraints{{Operator: ">=", Version: "1.0"}},
+		},
+		">= 1.0, < 2.0": {
+			want: VersionConstraints{
+				{Operator: ">=", Version: "1.0"},
+				{Operator: "<", Version: "2.0"},
+			},
+		},
+		">=1.0,<2.0,!=1.5.0": {
+			want: VersionConstraints{
+				{Operator: ">=", Version: "1.0"},
+				{Operator: "<", Version: "2.0"},
+				{Operator: "!=", Version: "1.5.0"},
+			},
+		},
+		"": {
+			wantErr: `invalid version constraint ""`,
+		},
+		">= 1.0,": {
+			wantErr: `invalid version constraint ""`,
+		},
+		"1.0,,2.0": {
+			wantErr: `invalid version constraint ""`,
+		},
+		", 1.0": {
+			wantErr: `invalid version constraint ""`,
+		},
+		"~>": {
+			wantErr: `invalid version constraint "~>"`,
+		},
+		"=> 1.0": {
+			wantErr: `invalid version constraint "=> 1.0"`,
+		},
+		"1.0.2_override": {
+			wantErr: `invalid version constraint "1.0.2_override"`,
+		},
+		">= 1.0 < 2.0": {
+			wantErr: `invalid version constraint ">= 1.0 < 2.0"`,
+		},
+	}
+
+	for raw, test := range tests {
+		t.Run(raw, func(t *testing.T) {
+			got, err := ParseVersionConstraints(raw)
+			if test.wantErr != "" {
+				if err == nil {
+					t.Fatalf("unexpected success; want error: %s", test.wantErr)
+				}
+				if got := err.Error(); got != test.wantErr {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", got, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrong result\n%s", diff)
+			}
+		})
+	}
+}